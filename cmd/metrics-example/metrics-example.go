@@ -3,10 +3,12 @@ package main
 import (
 	"errors"
 	"github.com/carbin-gun/awesome-metrics"
+	"github.com/carbin-gun/awesome-metrics/mechanism"
 	"log"
 	"math/rand"
 	"os"
-	// "syslog"
+	// "log/syslog"
+	// "github.com/carbin-gun/awesome-metrics/reporter"
 	"time"
 )
 
@@ -50,34 +52,32 @@ func main() {
 		}()
 	}
 
-	gf := metrics.NewGaugeFloat64()
-	r.Register("barfloat64", gf)
+	gf := metrics.NewGauge64()
+	r.Register("bar64", gf)
 	for i := 0; i < fanout; i++ {
 		go func() {
 			for {
-				g.Update(19.0)
+				gf.Update(19.0)
 				time.Sleep(300e6)
 			}
 		}()
 		go func() {
 			for {
-				g.Update(47.0)
+				gf.Update(47.0)
 				time.Sleep(400e6)
 			}
 		}()
 	}
 
-	hc := metrics.NewHealthcheck(func(h metrics.Healthcheck) {
+	r.Healthcheck("baz", func(h mechanism.Healthcheck) {
 		if 0 < rand.Intn(2) {
 			h.Healthy()
 		} else {
 			h.Unhealthy(errors.New("baz"))
 		}
 	})
-	r.Register("baz", hc)
 
-	s := metrics.NewExpDecaySample(1028, 0.015)
-	//s := metrics.NewUniformSample(1028)
+	s := metrics.NewExpDecayReservoir(1028, 0.015)
 	h := metrics.NewHistogram(s)
 	r.Register("bang", h)
 	for i := 0; i < fanout; i++ {
@@ -100,13 +100,13 @@ func main() {
 	for i := 0; i < fanout; i++ {
 		go func() {
 			for {
-				m.Mark(19)
+				m.Mark()
 				time.Sleep(300e6)
 			}
 		}()
 		go func() {
 			for {
-				m.Mark(47)
+				m.Mark()
 				time.Sleep(400e6)
 			}
 		}()
@@ -138,16 +138,12 @@ func main() {
 	/*
 		w, err := syslog.Dial("unixgram", "/dev/log", syslog.LOG_INFO, "metrics")
 		if nil != err { log.Fatalln(err) }
-		metrics.Syslog(r, 60e9, w)
+		reporter.Syslog(r.Registry, 60e9, w)
 	*/
 
 	/*
 		addr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:2003")
-		metrics.Graphite(r, 10e9, "metrics", addr)
-	*/
-
-	/*
-		stathat.Stathat(r, 10e9, "example@example.com")
+		(&reporter.GraphiteReporter{Addr: addr, Registry: r.Registry, FlushInterval: 10e9}).Report()
 	*/
 
 }
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/reporter"
+)
+
+//defaultRegistry holds the *RegistryWrapper of DefaultRegistry,swapped atomically so that tests can replace it
+//while other goroutines use the package-level functions
+var defaultRegistry atomic.Value
+
+func init() {
+	defaultRegistry.Store(NewRegistry())
+}
+
+//DefaultRegistry returns the process-wide registry used by the package-level functions
+func DefaultRegistry() *RegistryWrapper {
+	return defaultRegistry.Load().(*RegistryWrapper)
+}
+
+//SetDefaultRegistry replaces the DefaultRegistry,such as by a fresh one in a test,
+//and returns the function restoring the previous one.The metrics got before stay in the previous one
+func SetDefaultRegistry(r *RegistryWrapper) (restore func()) {
+	previous := defaultRegistry.Swap(r)
+	return func() { defaultRegistry.Store(previous) }
+}
+
+//GetOrRegisterTimer returns the timer of the DefaultRegistry registered under the name,registering a new one if there is none
+func GetOrRegisterTimer(name string, opts ...Option) mechanism.Timer {
+	return DefaultRegistry().Timer(name, opts...)
+}
+
+//GetOrRegisterCounter returns the counter of the DefaultRegistry registered under the name,registering a new one if there is none
+func GetOrRegisterCounter(name string, opts ...Option) mechanism.Counter {
+	return DefaultRegistry().Counter(name, opts...)
+}
+
+//GetOrRegisterMeter returns the meter of the DefaultRegistry registered under the name,registering a new one if there is none
+func GetOrRegisterMeter(name string, opts ...Option) mechanism.Meter {
+	return DefaultRegistry().Meter(name, opts...)
+}
+
+//GetOrRegisterHistogram returns the histogram of the DefaultRegistry registered under the name,registering a new one if there is none
+func GetOrRegisterHistogram(name string, opts ...Option) mechanism.Histogram {
+	return DefaultRegistry().Histogram(name, opts...)
+}
+
+//GetOrRegisterHistogramFloat64 returns the float64 histogram of the DefaultRegistry registered under the name,registering a new one if there is none
+func GetOrRegisterHistogramFloat64(name string, opts ...Option) mechanism.HistogramFloat64 {
+	return DefaultRegistry().HistogramFloat64(name, opts...)
+}
+
+//GetOrRegisterGauge returns the gauge of the DefaultRegistry registered under the name,registering a new one if there is none
+func GetOrRegisterGauge(name string, opts ...Option) mechanism.Gauge {
+	return DefaultRegistry().Gauge(name, opts...)
+}
+
+//GetOrRegisterGauge64 returns the float64 gauge of the DefaultRegistry registered under the name,registering a new one if there is none
+func GetOrRegisterGauge64(name string, opts ...Option) mechanism.Gauge64 {
+	return DefaultRegistry().Gauge64(name, opts...)
+}
+
+//StartDefaultReporting logs every metric of the DefaultRegistry every d in the background,
+//to stderr if the logger is nil.The registry is the DefaultRegistry at the time of the call.
+//Stop the returned reporter on shutdown for a final report.
+func StartDefaultReporting(d time.Duration, l *log.Logger) reporter.Reporter {
+	if l == nil {
+		l = log.New(os.Stderr, "metrics: ", log.Lmicroseconds)
+	}
+	rep := reporter.NewLogReporter(DefaultRegistry().Registry, d, l, reporter.Options{})
+	rep.Start(context.Background())
+	return rep
+}
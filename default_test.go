@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDefaultRegistryHelpers(t *testing.T) {
+	r := NewRegistry()
+	restore := SetDefaultRegistry(r)
+	defer restore()
+	tests := []struct {
+		name string
+		get  func() interface{}
+	}{
+		{"timer", func() interface{} { return GetOrRegisterTimer("timer") }},
+		{"counter", func() interface{} { return GetOrRegisterCounter("counter") }},
+		{"meter", func() interface{} { return GetOrRegisterMeter("meter") }},
+		{"histogram", func() interface{} { return GetOrRegisterHistogram("histogram") }},
+		{"histogram64", func() interface{} { return GetOrRegisterHistogramFloat64("histogram64") }},
+		{"gauge", func() interface{} { return GetOrRegisterGauge("gauge") }},
+		{"gauge64", func() interface{} { return GetOrRegisterGauge64("gauge64") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.get()
+			if m == nil || tt.get() != m {
+				t.Fatal("the registered metric isn't returned")
+			}
+			if r.Registry.Get(tt.name) != m {
+				t.Fatalf("%s isn't registered into the DefaultRegistry", tt.name)
+			}
+		})
+	}
+}
+
+func TestSetDefaultRegistry(t *testing.T) {
+	previous := DefaultRegistry()
+	r := NewRegistry()
+	restore := SetDefaultRegistry(r)
+	if DefaultRegistry() != r {
+		t.Fatal("DefaultRegistry() isn't the registry set")
+	}
+	GetOrRegisterCounter("c")
+	restore()
+	if DefaultRegistry() != previous {
+		t.Fatal("DefaultRegistry() isn't restored")
+	}
+	if previous.Registry.Get("c") != nil || r.Registry.Get("c") == nil {
+		t.Error("c isn't registered into the registry set only")
+	}
+}
+
+func TestSetDefaultRegistryConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			SetDefaultRegistry(NewRegistry())()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			GetOrRegisterCounter("c").Inc(1)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestStartDefaultReporting(t *testing.T) {
+	restore := SetDefaultRegistry(NewRegistry())
+	defer restore()
+	GetOrRegisterCounter("requests").Inc(3)
+	var buf bytes.Buffer
+	rep := StartDefaultReporting(time.Hour, log.New(&buf, "", 0))
+	rep.Stop()
+	if !strings.Contains(buf.String(), "requests") {
+		t.Fatalf("the final report %q doesn't hold requests", buf.String())
+	}
+}
@@ -27,14 +27,25 @@ type Meter interface {
 type Histogram interface {
 	//Counting interface
 	Count() int64
+	Sum() int64
 	//communication
 	Update(int64)
 	//snapshot data about histogram
 	Snapshot() output.Snapshot
 }
+type HistogramFloat64 interface {
+	//Counting interface
+	Count() int64
+	Sum() float64
+	//communication
+	Update(float64)
+	//snapshot data about histogram
+	Snapshot() output.SnapshotFloat64
+}
 type Timer interface {
 	//counting
 	Count() int64
+	Sum() int64
 	//from meter interface
 	Rate1() float64
 	Rate5() float64
@@ -65,3 +76,42 @@ type EWMA interface {
 	Update(int64)
 	Tick()
 }
+
+//Expirable is implemented by the metrics recording the time of their last update,
+//registries use it to evict idle metrics
+type Expirable interface {
+	LastUpdate() time.Time
+}
+
+//UpdateTracker is implemented by the Expirable metrics timestamping their updates only while they're tracked,
+//expiry sweepers track the metrics of the registry they sweep
+type UpdateTracker interface {
+	//TrackUpdates(true) starts timestamping the updates,TrackUpdates(false) stops it once every TrackUpdates(true) is matched
+	TrackUpdates(track bool)
+}
+
+//MemoryEstimator is implemented by the metrics able to estimate the bytes they hold,
+//registries use it to enforce a memory budget
+type MemoryEstimator interface {
+	EstimatedBytes() int64
+}
+
+//Healthcheck holds the result of its last check,nil error meaning healthy
+type Healthcheck interface {
+	Check()
+	Error() error
+	Healthy()
+	Unhealthy(error)
+}
+
+//Clock provides the current time to the metrics,replace the system one in tests
+type Clock interface {
+	Now() time.Time
+}
+
+//Persistent is implemented by the metrics able to save their state and restore it after a restart,
+//the state is encoded as JSON
+type Persistent interface {
+	SaveState(samples bool) ([]byte, error) //samples asks for the reservoir contents too
+	RestoreState(state []byte) error
+}
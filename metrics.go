@@ -1,9 +1,13 @@
 package metrics
 
 import (
+	"log"
+	"time"
+
 	"github.com/carbin-gun/awesome-metrics/mechanism"
 	"github.com/carbin-gun/awesome-metrics/metrics"
 	"github.com/carbin-gun/awesome-metrics/registry"
+	"github.com/carbin-gun/awesome-metrics/reporter"
 )
 
 /***
@@ -12,29 +16,186 @@ You can new a registry and the register all kinds of metrics supported for now f
 */
 type RegistryWrapper struct {
 	Registry registry.Registry
+	defaults *defaults //shared with the scopes
+	families *families //shared with the scopes
+	scope    string    //full scope path of the wrapped registry,empty for the root
 }
 
 func NewRegistry() *RegistryWrapper {
 	return &RegistryWrapper{
 		Registry: registry.NewRegistry(),
+		defaults: newDefaults(),
+		families: newFamilies(),
 	}
 }
 
 func NewPrefixRegistry(prefix string) *RegistryWrapper {
 	return &RegistryWrapper{
 		Registry: registry.NewPrefixRegistry(prefix),
+		defaults: newDefaults(),
+		families: newFamilies(),
+	}
+}
+
+//SetDefaults sets the options applied to every metric of the kind created from now on,before the ones given at creation,
+//such as r.SetDefaults(registry.KindTimer, WithBuckets(1e6, 1e7, 1e8))
+func (r *RegistryWrapper) SetDefaults(kind registry.MetricKind, opts ...Option) {
+	if r.defaults == nil {
+		r.defaults = newDefaults()
+	}
+	r.defaults.set(kind, opts)
+}
+
+//getOrRegister returns the metric of type T registered under the name,registering the one built by create if there is none.
+//If another kind of metric holds the name,an unregistered one is returned and the conflict is logged as a warning
+//by the Logger of the registry,use registry.GetOrRegisterTyped to get the error.
+func getOrRegister[T any](r *RegistryWrapper, kind registry.MetricKind, name string, opts []Option, create func(*metricConfig) T) T {
+	c := newMetricConfig(r.defaults.get(kind), opts)
+	m, err := registry.GetOrRegisterTyped(r.Registry, name, func() T { return create(c) }, c.metricOptions...)
+	if err != nil {
+		return create(c)
 	}
+	return m
+}
+
+//Timer returns the timer registered under the name,registering a new one if there is none.
+//If another kind of metric holds the name,an unregistered timer is returned.
+func (r *RegistryWrapper) Timer(name string, opts ...Option) mechanism.Timer {
+	return getOrRegister(r, registry.KindTimer, name, opts, (*metricConfig).newTimer)
+}
+
+//Counter returns the counter registered under the name,registering a new one if there is none.
+//If another kind of metric holds the name,an unregistered counter is returned.
+func (r *RegistryWrapper) Counter(name string, opts ...Option) mechanism.Counter {
+	return getOrRegister(r, registry.KindCounter, name, opts, func(*metricConfig) mechanism.Counter {
+		return metrics.NewCounter()
+	})
+}
+
+//Meter returns the meter registered under the name,registering a new one if there is none.
+//If another kind of metric holds the name,an unregistered meter is returned.
+func (r *RegistryWrapper) Meter(name string, opts ...Option) mechanism.Meter {
+	return getOrRegister(r, registry.KindMeter, name, opts, (*metricConfig).newMeter)
 }
 
-func (r *RegistryWrapper) Timer(name string) mechanism.Timer {
-	return r.Registry.GetOrRegister(name, metrics.NewTimer()).(mechanism.Timer)
+//Histogram returns the histogram registered under the name,registering a new one if there is none.
+//If another kind of metric holds the name,an unregistered histogram is returned.
+func (r *RegistryWrapper) Histogram(name string, opts ...Option) mechanism.Histogram {
+	return getOrRegister(r, registry.KindHistogram, name, opts, (*metricConfig).newHistogram)
 }
-func (r *RegistryWrapper) Counter(name string) mechanism.Counter {
-	return r.Registry.GetOrRegister(name, metrics.NewCounter()).(mechanism.Counter)
+
+//HistogramFloat64 returns the float64 histogram registered under the name,registering a new one if there is none.
+//If another kind of metric holds the name,an unregistered histogram is returned.
+func (r *RegistryWrapper) HistogramFloat64(name string, opts ...Option) mechanism.HistogramFloat64 {
+	return getOrRegister(r, registry.KindHistogramFloat64, name, opts, (*metricConfig).newHistogramFloat64)
+}
+
+//Gauge returns the gauge registered under the name,registering a new one if there is none.
+//If another kind of metric holds the name,an unregistered gauge is returned.
+func (r *RegistryWrapper) Gauge(name string, opts ...Option) mechanism.Gauge {
+	return getOrRegister(r, registry.KindGauge, name, opts, func(*metricConfig) mechanism.Gauge {
+		return NewGauge()
+	})
 }
-func (r *RegistryWrapper) Meter(name string) mechanism.Meter {
-	return r.Registry.GetOrRegister(name, metrics.NewMeter()).(mechanism.Meter)
+
+//Gauge64 returns the float64 gauge registered under the name,registering a new one if there is none.
+//If another kind of metric holds the name,an unregistered gauge is returned.
+func (r *RegistryWrapper) Gauge64(name string, opts ...Option) mechanism.Gauge64 {
+	return getOrRegister(r, registry.KindGauge64, name, opts, func(*metricConfig) mechanism.Gauge64 {
+		return NewGauge64()
+	})
 }
+
+//Healthcheck returns the healthcheck registered under the name,registering a new one running check if there is none.
+//If another kind of metric holds the name,an unregistered healthcheck is returned.
+func (r *RegistryWrapper) Healthcheck(name string, check func(mechanism.Healthcheck), opts ...Option) mechanism.Healthcheck {
+	return getOrRegister(r, registry.KindHealthcheck, name, opts, func(*metricConfig) mechanism.Healthcheck {
+		return metrics.NewHealthcheck(check)
+	})
+}
+
 func (r *RegistryWrapper) Each(f func(string, interface{})) {
 	r.Registry.Each(f)
 }
+
+//Scope returns a wrapper of the child registry whose metric names are prefixed by the scope name,sharing the storage of this one
+func (r *RegistryWrapper) Scope(name string) *RegistryWrapper {
+	if r.families == nil {
+		r.families = newFamilies()
+	}
+	scope := name
+	if r.scope != "" {
+		scope = r.scope + "." + name
+	}
+	return &RegistryWrapper{
+		Registry: r.Registry.Scope(name),
+		defaults: r.defaults,
+		families: r.families,
+		scope:    scope,
+	}
+}
+
+//StartExpiry unregisters the metrics of the wrapped registry left idle longer than policy.IdleTimeout,stop it with the returned sweeper
+func (r *RegistryWrapper) StartExpiry(policy registry.ExpiryPolicy) *registry.ExpirySweeper {
+	return registry.StartExpiry(r.Registry, policy)
+}
+
+//Register the given metric under the given name,see registry.Registry
+func (r *RegistryWrapper) Register(name string, i interface{}) error {
+	return r.Registry.Register(name, i)
+}
+
+//NewCounter creates an unregistered counter
+func NewCounter() mechanism.Counter {
+	return metrics.NewCounter()
+}
+
+//NewGauge creates an unregistered gauge
+func NewGauge() mechanism.Gauge {
+	return &metrics.StandardGauge{}
+}
+
+//NewGauge64 creates an unregistered float64 gauge
+func NewGauge64() mechanism.Gauge64 {
+	return &metrics.StandardGauge64{}
+}
+
+//NewMeter creates an unregistered meter
+func NewMeter() mechanism.Meter {
+	return metrics.NewMeter()
+}
+
+//NewTimer creates an unregistered timer with the default exponentially decaying reservoir
+func NewTimer() mechanism.Timer {
+	return metrics.NewTimer()
+}
+
+//NewHealthcheck creates an unregistered healthcheck running f on every Check
+func NewHealthcheck(f func(mechanism.Healthcheck)) mechanism.Healthcheck {
+	return metrics.NewHealthcheck(f)
+}
+
+//NewHistogram creates an unregistered histogram sampling into the reservoir
+func NewHistogram(reservoir metrics.Reservoir) mechanism.Histogram {
+	return metrics.NewHistogram(reservoir)
+}
+
+//NewHistogramFloat64 creates an unregistered float64 histogram sampling into the reservoir
+func NewHistogramFloat64(reservoir metrics.ReservoirFloat64) mechanism.HistogramFloat64 {
+	return metrics.NewHistogramFloat64(reservoir)
+}
+
+//NewExpDecayReservoir creates an exponentially decaying reservoir,such as NewExpDecayReservoir(1028, 0.015)
+func NewExpDecayReservoir(reservoirSize int64, alpha float64) metrics.Reservoir {
+	return metrics.NewExpDecayReservoir(reservoirSize, alpha)
+}
+
+//NewUniformReservoir creates a reservoir sampling uniformly over the whole life of the histogram,such as NewUniformReservoir(1028)
+func NewUniformReservoir(reservoirSize int64) metrics.Reservoir {
+	return metrics.NewUniformReservoir(reservoirSize)
+}
+
+//Log outputs each metric of the registry every d using the logger,it blocks
+func Log(r *RegistryWrapper, d time.Duration, l *log.Logger) {
+	reporter.Log(r.Registry, d, l)
+}
@@ -0,0 +1,283 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//BucketHistogram counts values into buckets defined up front instead of sampling them into a reservoir.
+//Updates hold the read lock and run concurrently,snapshots hold the write lock so that they read every field at once
+type BucketHistogram struct {
+	lastUpdate
+	mutex  sync.RWMutex
+	count  int64
+	sum    int64
+	min    int64
+	max    int64
+	bounds []float64 //sorted upper bounds,without the +Inf one
+	counts []int64   //len(bounds)+1,the last one is the +Inf bucket
+}
+
+//NewBucketHistogram creates a histogram with the given upper bounds,a +Inf bucket is always appended
+func NewBucketHistogram(bounds []float64) mechanism.Histogram {
+	sorted := make([]float64, 0, len(bounds))
+	for _, b := range bounds {
+		if !math.IsNaN(b) && !math.IsInf(b, 1) {
+			sorted = append(sorted, b)
+		}
+	}
+	sort.Float64s(sorted)
+	unique := sorted[:0]
+	for i, b := range sorted {
+		if i == 0 || b != sorted[i-1] {
+			unique = append(unique, b)
+		}
+	}
+	return &BucketHistogram{
+		min:    math.MaxInt64,
+		max:    math.MinInt64,
+		bounds: unique,
+		counts: make([]int64, len(unique)+1),
+	}
+}
+
+//NewLinearBucketHistogram creates count buckets of the given width,the first upper bound is start,see LinearBuckets
+func NewLinearBucketHistogram(start, width float64, count int) (mechanism.Histogram, error) {
+	bounds, err := LinearBuckets(start, width, count)
+	if err != nil {
+		return nil, err
+	}
+	return NewBucketHistogram(bounds), nil
+}
+
+//NewExponentialBucketHistogram creates count buckets,each upper bound is factor times the previous one,see ExponentialBuckets
+func NewExponentialBucketHistogram(start, factor float64, count int) (mechanism.Histogram, error) {
+	bounds, err := ExponentialBuckets(start, factor, count)
+	if err != nil {
+		return nil, err
+	}
+	return NewBucketHistogram(bounds), nil
+}
+
+//LinearBuckets returns count upper bounds starting at start and spaced by width,an error if count isn't positive
+//or width isn't,as a histogram with only the +Inf bucket is never what is wanted
+func LinearBuckets(start, width float64, count int) ([]float64, error) {
+	if count < 1 {
+		return nil, errors.New("metrics: LinearBuckets needs a positive count")
+	}
+	if width <= 0 || math.IsNaN(width) || math.IsInf(width, 0) || math.IsNaN(start) || math.IsInf(start, 0) {
+		return nil, errors.New("metrics: LinearBuckets needs a finite start and a positive width")
+	}
+	bounds := make([]float64, 0, count)
+	for i := 0; i < count; i++ {
+		bounds = append(bounds, start+float64(i)*width)
+	}
+	return bounds, nil
+}
+
+//ExponentialBuckets returns count upper bounds starting at start and multiplied by factor,an error if count isn't positive,
+//start isn't positive or factor isn't greater than 1
+func ExponentialBuckets(start, factor float64, count int) ([]float64, error) {
+	if count < 1 {
+		return nil, errors.New("metrics: ExponentialBuckets needs a positive count")
+	}
+	if start <= 0 || math.IsInf(start, 0) || math.IsNaN(start) {
+		return nil, errors.New("metrics: ExponentialBuckets needs a positive start")
+	}
+	if factor <= 1 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return nil, errors.New("metrics: ExponentialBuckets needs a factor greater than 1")
+	}
+	bounds := make([]float64, 0, count)
+	for i := 0; i < count; i++ {
+		bounds = append(bounds, start)
+		start *= factor
+	}
+	return bounds, nil
+}
+
+//Counting interface
+func (h *BucketHistogram) Count() int64 {
+	return atomic.LoadInt64(&h.count)
+}
+
+//Sum of all the updated values
+func (h *BucketHistogram) Sum() int64 {
+	return atomic.LoadInt64(&h.sum)
+}
+
+//communication
+func (h *BucketHistogram) Update(val int64) {
+	h.touch()
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for {
+		min := atomic.LoadInt64(&h.min)
+		if val >= min || atomic.CompareAndSwapInt64(&h.min, min, val) {
+			break
+		}
+	}
+	for {
+		max := atomic.LoadInt64(&h.max)
+		if val <= max || atomic.CompareAndSwapInt64(&h.max, max, val) {
+			break
+		}
+	}
+	atomic.AddInt64(&h.sum, val)
+	atomic.AddInt64(&h.counts[sort.SearchFloat64s(h.bounds, float64(val))], 1)
+	atomic.AddInt64(&h.count, 1)
+}
+
+//snapshot data about histogram,no update runs while it is read,so that the count,sum,min,max and buckets agree
+func (h *BucketHistogram) Snapshot() output.Snapshot {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	s := &BucketSnapshot{
+		count:  h.count,
+		sum:    h.sum,
+		bounds: h.bounds,
+		counts: append([]int64(nil), h.counts...),
+	}
+	if s.count > 0 {
+		s.min, s.max = h.min, h.max
+	}
+	return s
+}
+
+//BucketSnapshot is a read-only copy of a BucketHistogram,quantiles are interpolated inside the buckets
+type BucketSnapshot struct {
+	count    int64
+	sum      int64
+	min, max int64
+	bounds   []float64
+	counts   []int64
+}
+
+func (s *BucketSnapshot) Count() int64 {
+	return s.count
+}
+
+func (s *BucketSnapshot) Sum() int64 {
+	return s.sum
+}
+
+//Buckets returns the cumulative buckets,ending with the +Inf one
+func (s *BucketSnapshot) Buckets() []output.Bucket {
+	buckets := make([]output.Bucket, len(s.counts))
+	var cumulative int64
+	for i, c := range s.counts {
+		cumulative += c
+		buckets[i].Count = cumulative
+		if i < len(s.bounds) {
+			buckets[i].UpperBound = s.bounds[i]
+		} else {
+			buckets[i].UpperBound = math.Inf(1)
+		}
+	}
+	return buckets
+}
+
+//lower and upper limit of the i-th bucket,clamped to the observed min and max
+func (s *BucketSnapshot) limits(i int) (float64, float64) {
+	lower, upper := float64(s.min), float64(s.max)
+	if i > 0 && s.bounds[i-1] > lower {
+		lower = s.bounds[i-1]
+	}
+	if i < len(s.bounds) && s.bounds[i] < upper {
+		upper = s.bounds[i]
+	}
+	return lower, upper
+}
+
+//Value returns the value at the given quantile,interpolated linearly inside the bucket holding it
+func (s *BucketSnapshot) Value(quantile float64) float64 {
+	if quantile < 0.0 || quantile > 1.0 || math.IsNaN(quantile) || s.count == 0 {
+		return 0
+	}
+	rank := quantile * float64(s.count)
+	var cumulative int64
+	for i, c := range s.counts {
+		if c == 0 || float64(cumulative+c) < rank {
+			cumulative += c
+			continue
+		}
+		lower, upper := s.limits(i)
+		return lower + (upper-lower)*(rank-float64(cumulative))/float64(c)
+	}
+	return float64(s.max)
+}
+
+//Values is always nil,no sample is kept by a bucket histogram
+func (s *BucketSnapshot) Values() []float64 {
+	return nil
+}
+
+func (s *BucketSnapshot) Size() int64 {
+	return s.count
+}
+
+func (s *BucketSnapshot) Max() int64 {
+	return s.max
+}
+
+func (s *BucketSnapshot) Min() int64 {
+	return s.min
+}
+
+func (s *BucketSnapshot) Mean() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return float64(s.sum) / float64(s.count)
+}
+
+//StdDev is approximated with the midpoint of every bucket
+func (s *BucketSnapshot) StdDev() float64 {
+	if s.count <= 1 {
+		return 0
+	}
+	mean := s.Mean()
+	var sum float64
+	for i, c := range s.counts {
+		if c == 0 {
+			continue
+		}
+		lower, upper := s.limits(i)
+		diff := (lower+upper)/2 - mean
+		sum += diff * diff * float64(c)
+	}
+	return math.Sqrt(sum / float64(s.count-1))
+}
+
+func (s *BucketSnapshot) Median() float64 {
+	return s.Value(0.5)
+}
+func (s *BucketSnapshot) Get75thPercentile() float64 {
+	return s.Value(0.75)
+}
+func (s *BucketSnapshot) Get95thPercentile() float64 {
+	return s.Value(0.95)
+}
+func (s *BucketSnapshot) Get98thPercentile() float64 {
+	return s.Value(0.98)
+}
+func (s *BucketSnapshot) Get99thPercentile() float64 {
+	return s.Value(0.99)
+}
+func (s *BucketSnapshot) Get999thPercentile() float64 {
+	return s.Value(0.999)
+}
+
+//Percentiles returns the values at output.DefaultPercentiles
+func (s *BucketSnapshot) Percentiles() []float64 {
+	values := make([]float64, len(output.DefaultPercentiles))
+	for i, p := range output.DefaultPercentiles {
+		values[i] = s.Value(p)
+	}
+	return values
+}
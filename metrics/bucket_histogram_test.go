@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+//a histogram of buckets up to 10,20,30 and 40 holding 1 to 40
+func bucketSnapshot() *BucketSnapshot {
+	h := NewBucketHistogram([]float64{10, 20, 30, 40})
+	for v := int64(1); v <= 40; v++ {
+		h.Update(v)
+	}
+	return h.Snapshot().(*BucketSnapshot)
+}
+
+func TestBucketSnapshotValue(t *testing.T) {
+	s := bucketSnapshot()
+	tests := []struct {
+		quantile float64
+		want     float64
+	}{
+		{0, 1},
+		{0.1, 4.6},
+		{0.25, 10},
+		{0.5, 20},
+		{0.95, 38},
+		{1, 40},
+		{-0.1, 0},
+		{1.1, 0},
+		{math.NaN(), 0},
+	}
+	for _, tt := range tests {
+		if got := s.Value(tt.quantile); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Value(%v) = %v,want %v", tt.quantile, got, tt.want)
+		}
+	}
+}
+
+func TestBucketSnapshotValueBeyondBounds(t *testing.T) {
+	h := NewBucketHistogram([]float64{1})
+	h.Update(5)
+	h.Update(7)
+	if got := h.Snapshot().(*BucketSnapshot).Value(0.5); got != 6 {
+		t.Errorf("Value(0.5) = %v,want 6 interpolated between min and max in the +Inf bucket", got)
+	}
+	if got := NewBucketHistogram([]float64{1}).Snapshot().(*BucketSnapshot).Value(0.5); got != 0 {
+		t.Errorf("Value(0.5) of an empty histogram = %v,want 0", got)
+	}
+}
+
+func TestBucketSnapshotStats(t *testing.T) {
+	s := bucketSnapshot()
+	bounds := []float64{10, 20, 30, 40, math.Inf(1)}
+	counts := []int64{10, 20, 30, 40, 40}
+	buckets := s.Buckets()
+	if len(buckets) != len(bounds) {
+		t.Fatalf("Buckets() = %v,want %d buckets", buckets, len(bounds))
+	}
+	for i, b := range buckets {
+		if b.UpperBound != bounds[i] || b.Count != counts[i] {
+			t.Errorf("Buckets()[%d] = %+v,want %v cumulated up to %v", i, b, counts[i], bounds[i])
+		}
+	}
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"Count", float64(s.Count()), 40},
+		{"Sum", float64(s.Sum()), 820},
+		{"Min", float64(s.Min()), 1},
+		{"Max", float64(s.Max()), 40},
+		{"Mean", s.Mean(), 20.5},
+		{"StdDev", s.StdDev(), 11.160254569286582}, //from the midpoints 5.5,15,25 and 35
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-9 {
+			t.Errorf("%s() = %v,want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestBucketLayouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		bounds  func() ([]float64, error)
+		want    []float64
+		wantErr bool
+	}{
+		{"linear", func() ([]float64, error) { return LinearBuckets(1, 2, 3) }, []float64{1, 3, 5}, false},
+		{"linear negative start", func() ([]float64, error) { return LinearBuckets(-1, 1, 2) }, []float64{-1, 0}, false},
+		{"linear no count", func() ([]float64, error) { return LinearBuckets(1, 2, 0) }, nil, true},
+		{"linear no width", func() ([]float64, error) { return LinearBuckets(1, 0, 3) }, nil, true},
+		{"linear NaN start", func() ([]float64, error) { return LinearBuckets(math.NaN(), 1, 3) }, nil, true},
+		{"exponential", func() ([]float64, error) { return ExponentialBuckets(1, 2, 4) }, []float64{1, 2, 4, 8}, false},
+		{"exponential no count", func() ([]float64, error) { return ExponentialBuckets(1, 2, -1) }, nil, true},
+		{"exponential zero start", func() ([]float64, error) { return ExponentialBuckets(0, 2, 4) }, nil, true},
+		{"exponential factor 1", func() ([]float64, error) { return ExponentialBuckets(1, 1, 4) }, nil, true},
+		{"exponential infinite factor", func() ([]float64, error) { return ExponentialBuckets(1, math.Inf(1), 4) }, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.bounds()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v,want an error: %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("bounds = %v,want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("bounds = %v,want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBucketHistogramSortsBounds(t *testing.T) {
+	h := NewBucketHistogram([]float64{3, 1, math.NaN(), 1, math.Inf(1)}).(*BucketHistogram)
+	if len(h.bounds) != 2 || h.bounds[0] != 1 || h.bounds[1] != 3 {
+		t.Errorf("bounds = %v,want [1 3]", h.bounds)
+	}
+}
+
+//snapshots taken during updates see a count and a sum of the same updates
+func TestBucketSnapshotConsistent(t *testing.T) {
+	h := NewBucketHistogram([]float64{1, 10})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10000; j++ {
+				h.Update(5)
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		if s := h.Snapshot(); s.Count() > 0 && s.Mean() != 5 {
+			t.Fatalf("Mean() = %v with count %d and sum %d,want 5", s.Mean(), s.Count(), s.Sum())
+		}
+	}
+	wg.Wait()
+}
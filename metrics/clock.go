@@ -0,0 +1,16 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+)
+
+//SystemClock is the clock used by default,reading time.Now
+var SystemClock mechanism.Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
@@ -7,6 +7,7 @@ import (
 )
 
 type StandardCounter struct {
+	lastUpdate
 	count int64
 }
 
@@ -20,10 +21,12 @@ func (c *StandardCounter) Count() int64 {
 }
 
 func (c *StandardCounter) Dec(i int64) {
+	c.touch()
 	atomic.AddInt64(&c.count, -i)
 }
 
 // Inc increments the counter by the given amount.
 func (c *StandardCounter) Inc(i int64) {
+	c.touch()
 	atomic.AddInt64(&c.count, i)
 }
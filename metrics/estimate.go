@@ -0,0 +1,47 @@
+package metrics
+
+import "github.com/carbin-gun/awesome-metrics/mechanism"
+
+//rough sizes used to estimate the memory held by the metrics
+const (
+	metricOverheadBytes = 64
+	weightedSampleBytes = 96 //a skiplist node holding the encoded sample
+	uniformSampleBytes  = 8
+	bucketBytes         = 16 //upper bound and count
+)
+
+func estimatedBytes(i interface{}) int64 {
+	if e, ok := i.(mechanism.MemoryEstimator); ok {
+		return e.EstimatedBytes()
+	}
+	return 0
+}
+
+//EstimatedBytes is the memory held by the reservoir once full
+func (r *ExpDecayReservoir) EstimatedBytes() int64 {
+	return metricOverheadBytes + r.reservoirSize*weightedSampleBytes
+}
+
+func (r *ExpDecayReservoirFloat64) EstimatedBytes() int64 {
+	return r.reservoir.EstimatedBytes()
+}
+
+func (r *UniformReservoirFloat64) EstimatedBytes() int64 {
+	return metricOverheadBytes + r.reservoirSize*uniformSampleBytes
+}
+
+func (histogram *StandardHistogram) EstimatedBytes() int64 {
+	return metricOverheadBytes + estimatedBytes(histogram.reservoir)
+}
+
+func (histogram *StandardHistogramFloat64) EstimatedBytes() int64 {
+	return metricOverheadBytes + estimatedBytes(histogram.reservoir)
+}
+
+func (h *BucketHistogram) EstimatedBytes() int64 {
+	return metricOverheadBytes + int64(len(h.counts))*bucketBytes
+}
+
+func (timer *StandardTimer) EstimatedBytes() int64 {
+	return metricOverheadBytes + estimatedBytes(timer.histogram) + estimatedBytes(timer.meter)
+}
@@ -4,6 +4,7 @@ import (
 	"math"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/carbin-gun/awesome-metrics/mechanism"
 )
@@ -36,6 +37,11 @@ func NewEWMA15() mechanism.EWMA {
 	return newEWMA(1 - math.Exp(-5.0/60.0/15))
 }
 
+// NewEWMA constructs a new EWMA for a moving average over the window,such as 5 minutes.
+func NewEWMA(window time.Duration) mechanism.EWMA {
+	return newEWMA(1 - math.Exp(-float64(TickInterval)/float64(window)))
+}
+
 // Rate returns the moving average rate of events per second.
 func (a *StandardEWMA) Rate() float64 {
 	a.mutex.Lock()
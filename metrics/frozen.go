@@ -0,0 +1,309 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//FrozenStats are the statistics of a histogram or timer as reported,rather than its samples
+type FrozenStats struct {
+	Count       int64
+	Sum         float64
+	Min         float64
+	Max         float64
+	Mean        float64
+	StdDev      float64
+	Percentiles map[float64]float64 //value per quantile,such as 0.99
+	Buckets     []output.Bucket     //cumulative buckets,nil for sampling histograms
+}
+
+//value returns the value of the reported quantile nearest to the given one,0 if none was reported
+func (s *FrozenStats) value(quantile float64) float64 {
+	if v, ok := s.Percentiles[quantile]; ok {
+		return v
+	}
+	nearest, value := math.Inf(1), 0.0
+	for q, v := range s.Percentiles {
+		if d := math.Abs(q - quantile); d < nearest {
+			nearest, value = d, v
+		}
+	}
+	return value
+}
+
+//values of the reported quantiles,sorted by quantile
+func (s *FrozenStats) values() []float64 {
+	quantiles := make([]float64, 0, len(s.Percentiles))
+	for q := range s.Percentiles {
+		quantiles = append(quantiles, q)
+	}
+	sort.Float64s(quantiles)
+	values := make([]float64, len(quantiles))
+	for i, q := range quantiles {
+		values[i] = s.Percentiles[q]
+	}
+	return values
+}
+
+func (s *FrozenStats) percentiles() []float64 {
+	values := make([]float64, len(output.DefaultPercentiles))
+	for i, p := range output.DefaultPercentiles {
+		values[i] = s.value(p)
+	}
+	return values
+}
+
+//FrozenSnapshot is a snapshot rebuilt from reported statistics,Value returns the nearest reported percentile
+type FrozenSnapshot struct {
+	stats FrozenStats
+}
+
+//NewFrozenSnapshot returns a snapshot of the statistics,also implementing output.Bucketed if they have buckets
+func NewFrozenSnapshot(stats FrozenStats) output.Snapshot {
+	if stats.Buckets != nil {
+		return &frozenBucketSnapshot{FrozenSnapshot{stats: stats}}
+	}
+	return &FrozenSnapshot{stats: stats}
+}
+
+func (s *FrozenSnapshot) Count() int64 {
+	return s.stats.Count
+}
+
+func (s *FrozenSnapshot) Sum() int64 {
+	return int64(math.Round(s.stats.Sum))
+}
+
+func (s *FrozenSnapshot) Value(quantile float64) float64 {
+	return s.stats.value(quantile)
+}
+
+//Values returns the reported percentiles,the samples are gone
+func (s *FrozenSnapshot) Values() []float64 {
+	return s.stats.values()
+}
+
+func (s *FrozenSnapshot) Size() int64 {
+	return int64(len(s.stats.Percentiles))
+}
+
+func (s *FrozenSnapshot) Max() int64 {
+	return int64(math.Round(s.stats.Max))
+}
+
+func (s *FrozenSnapshot) Min() int64 {
+	return int64(math.Round(s.stats.Min))
+}
+
+func (s *FrozenSnapshot) Mean() float64 {
+	return s.stats.Mean
+}
+
+func (s *FrozenSnapshot) StdDev() float64 {
+	return s.stats.StdDev
+}
+
+func (s *FrozenSnapshot) Median() float64 {
+	return s.Value(0.5)
+}
+func (s *FrozenSnapshot) Get75thPercentile() float64 {
+	return s.Value(0.75)
+}
+func (s *FrozenSnapshot) Get95thPercentile() float64 {
+	return s.Value(0.95)
+}
+func (s *FrozenSnapshot) Get98thPercentile() float64 {
+	return s.Value(0.98)
+}
+func (s *FrozenSnapshot) Get99thPercentile() float64 {
+	return s.Value(0.99)
+}
+func (s *FrozenSnapshot) Get999thPercentile() float64 {
+	return s.Value(0.999)
+}
+
+//Percentiles returns the values at output.DefaultPercentiles
+func (s *FrozenSnapshot) Percentiles() []float64 {
+	return s.stats.percentiles()
+}
+
+type frozenBucketSnapshot struct {
+	FrozenSnapshot
+}
+
+func (s *frozenBucketSnapshot) Buckets() []output.Bucket {
+	return s.stats.Buckets
+}
+
+//FrozenSnapshotFloat64 is the float64 FrozenSnapshot
+type FrozenSnapshotFloat64 struct {
+	stats FrozenStats
+}
+
+func NewFrozenSnapshotFloat64(stats FrozenStats) output.SnapshotFloat64 {
+	return &FrozenSnapshotFloat64{stats: stats}
+}
+
+func (s *FrozenSnapshotFloat64) Count() int64 {
+	return s.stats.Count
+}
+
+func (s *FrozenSnapshotFloat64) Sum() float64 {
+	return s.stats.Sum
+}
+
+func (s *FrozenSnapshotFloat64) Value(quantile float64) float64 {
+	return s.stats.value(quantile)
+}
+
+func (s *FrozenSnapshotFloat64) Values() []float64 {
+	return s.stats.values()
+}
+
+func (s *FrozenSnapshotFloat64) Size() int64 {
+	return int64(len(s.stats.Percentiles))
+}
+
+func (s *FrozenSnapshotFloat64) Max() float64 {
+	return s.stats.Max
+}
+
+func (s *FrozenSnapshotFloat64) Min() float64 {
+	return s.stats.Min
+}
+
+func (s *FrozenSnapshotFloat64) Mean() float64 {
+	return s.stats.Mean
+}
+
+func (s *FrozenSnapshotFloat64) StdDev() float64 {
+	return s.stats.StdDev
+}
+
+func (s *FrozenSnapshotFloat64) Percentiles() []float64 {
+	return s.stats.percentiles()
+}
+
+//FrozenRates are the per second rates of a meter or timer
+type FrozenRates struct {
+	Rate1, Rate5, Rate15, RateMean float64
+}
+
+//FrozenCounter is a read-only counter,updates are discarded
+type FrozenCounter struct {
+	count int64
+}
+
+func NewFrozenCounter(count int64) *FrozenCounter {
+	return &FrozenCounter{count: count}
+}
+
+func (c *FrozenCounter) Count() int64 { return c.count }
+func (c *FrozenCounter) Dec(int64)    {}
+func (c *FrozenCounter) Inc(int64)    {}
+
+//FrozenGauge is a read-only gauge,updates are discarded
+type FrozenGauge struct {
+	value int64
+}
+
+func NewFrozenGauge(value int64) *FrozenGauge {
+	return &FrozenGauge{value: value}
+}
+
+func (g *FrozenGauge) Value() int64 { return g.value }
+func (g *FrozenGauge) Update(int64) {}
+
+//FrozenGauge64 is a read-only float64 gauge,updates are discarded
+type FrozenGauge64 struct {
+	value float64
+}
+
+func NewFrozenGauge64(value float64) *FrozenGauge64 {
+	return &FrozenGauge64{value: value}
+}
+
+func (g *FrozenGauge64) Value() float64 { return g.value }
+func (g *FrozenGauge64) Update(float64) {}
+
+//FrozenHistogram is a read-only histogram,updates are discarded
+type FrozenHistogram struct {
+	snapshot output.Snapshot
+}
+
+func NewFrozenHistogram(stats FrozenStats) *FrozenHistogram {
+	return &FrozenHistogram{snapshot: NewFrozenSnapshot(stats)}
+}
+
+func (h *FrozenHistogram) Count() int64              { return h.snapshot.Count() }
+func (h *FrozenHistogram) Sum() int64                { return h.snapshot.Sum() }
+func (h *FrozenHistogram) Update(int64)              {}
+func (h *FrozenHistogram) Snapshot() output.Snapshot { return h.snapshot }
+
+//FrozenHistogramFloat64 is a read-only float64 histogram,updates are discarded
+type FrozenHistogramFloat64 struct {
+	snapshot output.SnapshotFloat64
+}
+
+func NewFrozenHistogramFloat64(stats FrozenStats) *FrozenHistogramFloat64 {
+	return &FrozenHistogramFloat64{snapshot: NewFrozenSnapshotFloat64(stats)}
+}
+
+func (h *FrozenHistogramFloat64) Count() int64                     { return h.snapshot.Count() }
+func (h *FrozenHistogramFloat64) Sum() float64                     { return h.snapshot.Sum() }
+func (h *FrozenHistogramFloat64) Update(float64)                   {}
+func (h *FrozenHistogramFloat64) Snapshot() output.SnapshotFloat64 { return h.snapshot }
+
+//FrozenMeter is a read-only meter,marks are discarded
+type FrozenMeter struct {
+	count int64
+	rates FrozenRates
+}
+
+func NewFrozenMeter(count int64, rates FrozenRates) *FrozenMeter {
+	return &FrozenMeter{count: count, rates: rates}
+}
+
+func (m *FrozenMeter) Count() int64      { return m.count }
+func (m *FrozenMeter) Rate1() float64    { return m.rates.Rate1 }
+func (m *FrozenMeter) Rate5() float64    { return m.rates.Rate5 }
+func (m *FrozenMeter) Rate15() float64   { return m.rates.Rate15 }
+func (m *FrozenMeter) RateMean() float64 { return m.rates.RateMean }
+func (m *FrozenMeter) Mark()             {}
+
+//FrozenTimer is a read-only timer with durations in nanoseconds,updates are discarded and the timed functions are still run
+type FrozenTimer struct {
+	snapshot output.Snapshot
+	rates    FrozenRates
+}
+
+func NewFrozenTimer(stats FrozenStats, rates FrozenRates) *FrozenTimer {
+	return &FrozenTimer{snapshot: NewFrozenSnapshot(stats), rates: rates}
+}
+
+func (t *FrozenTimer) Count() int64              { return t.snapshot.Count() }
+func (t *FrozenTimer) Sum() int64                { return t.snapshot.Sum() }
+func (t *FrozenTimer) Rate1() float64            { return t.rates.Rate1 }
+func (t *FrozenTimer) Rate5() float64            { return t.rates.Rate5 }
+func (t *FrozenTimer) Rate15() float64           { return t.rates.Rate15 }
+func (t *FrozenTimer) RateMean() float64         { return t.rates.RateMean }
+func (t *FrozenTimer) Snapshot() output.Snapshot { return t.snapshot }
+func (t *FrozenTimer) Time(f func())             { f() }
+func (t *FrozenTimer) Update(time.Duration)      {}
+
+//FrozenHealthcheck is a read-only healthcheck holding the error of its last check
+type FrozenHealthcheck struct {
+	err error
+}
+
+func NewFrozenHealthcheck(err error) *FrozenHealthcheck {
+	return &FrozenHealthcheck{err: err}
+}
+
+func (h *FrozenHealthcheck) Check()          {}
+func (h *FrozenHealthcheck) Error() error    { return h.err }
+func (h *FrozenHealthcheck) Healthy()        {}
+func (h *FrozenHealthcheck) Unhealthy(error) {}
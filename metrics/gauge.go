@@ -4,11 +4,13 @@ import "sync/atomic"
 
 //implements Gauge interface
 type StandardGauge struct {
+	lastUpdate
 	value int64
 }
 
 // Update updates the gauge's value.
 func (g *StandardGauge) Update(v int64) {
+	g.touch()
 	atomic.StoreInt64(&g.value, v)
 }
 
@@ -4,12 +4,14 @@ import "sync"
 
 //implements Gauge interface
 type StandardGauge64 struct {
+	lastUpdate
 	value float64
 	mutex sync.RWMutex
 }
 
 // Update updates the gauge's value.
 func (g *StandardGauge64) Update(v float64) {
+	g.touch()
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
 	g.value = v
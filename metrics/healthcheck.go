@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+)
+
+//StandardHealthcheck runs the check function on Check,which reports the result with Healthy or Unhealthy
+type StandardHealthcheck struct {
+	err   error
+	check func(mechanism.Healthcheck)
+	mutex sync.RWMutex
+}
+
+//NewHealthcheck creates a healthcheck running f on every Check,it's healthy until f says otherwise
+func NewHealthcheck(f func(mechanism.Healthcheck)) mechanism.Healthcheck {
+	return &StandardHealthcheck{check: f}
+}
+
+//Check runs the check function
+func (h *StandardHealthcheck) Check() {
+	if h.check != nil {
+		h.check(h)
+	}
+}
+
+//Error returns the error of the last check,nil if healthy
+func (h *StandardHealthcheck) Error() error {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.err
+}
+
+//Healthy marks the healthcheck as healthy
+func (h *StandardHealthcheck) Healthy() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.err = nil
+}
+
+//Unhealthy marks the healthcheck as unhealthy with the error
+func (h *StandardHealthcheck) Unhealthy(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.err = err
+}
@@ -8,7 +8,9 @@ import (
 )
 
 type StandardHistogram struct {
+	lastUpdate
 	count     int64
+	sum       int64
 	reservoir Reservoir
 }
 
@@ -24,13 +26,24 @@ func (histogram *StandardHistogram) Count() int64 {
 	return atomic.LoadInt64(&histogram.count)
 }
 
+//Sum of all the updated values
+func (histogram *StandardHistogram) Sum() int64 {
+	return atomic.LoadInt64(&histogram.sum)
+}
+
 //communication
 func (histogram *StandardHistogram) Update(val int64) {
-	atomic.AddInt64(&histogram.count, val)
+	histogram.touch()
+	atomic.AddInt64(&histogram.count, 1)
+	atomic.AddInt64(&histogram.sum, val)
 	histogram.reservoir.Update(val)
 }
 
 //snapshot data about histogram
 func (histogram *StandardHistogram) Snapshot() output.Snapshot {
-	return histogram.reservoir.Snapshot()
+	return &totalsSnapshot{
+		Snapshot: histogram.reservoir.Snapshot(),
+		count:    histogram.Count(),
+		sum:      histogram.Sum(),
+	}
 }
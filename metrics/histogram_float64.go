@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//StandardHistogramFloat64 is the histogram of float64 values,such as ratios and scores
+type StandardHistogramFloat64 struct {
+	lastUpdate
+	count     int64
+	sumBits   uint64 //bits of the float64 sum,updated by compare and swap
+	reservoir ReservoirFloat64
+}
+
+func NewHistogramFloat64(reservoir ReservoirFloat64) mechanism.HistogramFloat64 {
+	return &StandardHistogramFloat64{reservoir: reservoir}
+}
+
+//Counting interface
+func (histogram *StandardHistogramFloat64) Count() int64 {
+	return atomic.LoadInt64(&histogram.count)
+}
+
+//Sum of all the updated values
+func (histogram *StandardHistogramFloat64) Sum() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&histogram.sumBits))
+}
+
+//communication
+func (histogram *StandardHistogramFloat64) Update(val float64) {
+	histogram.touch()
+	atomic.AddInt64(&histogram.count, 1)
+	for {
+		old := atomic.LoadUint64(&histogram.sumBits)
+		sum := math.Float64bits(math.Float64frombits(old) + val)
+		if atomic.CompareAndSwapUint64(&histogram.sumBits, old, sum) {
+			break
+		}
+	}
+	histogram.reservoir.Update(val)
+}
+
+//snapshot data about histogram
+func (histogram *StandardHistogramFloat64) Snapshot() output.SnapshotFloat64 {
+	return &totalsSnapshotFloat64{
+		SnapshotFloat64: histogram.reservoir.Snapshot(),
+		count:           histogram.Count(),
+		sum:             histogram.Sum(),
+	}
+}
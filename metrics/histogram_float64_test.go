@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogramFloat64(t *testing.T) {
+	reservoirs := []struct {
+		name      string
+		reservoir ReservoirFloat64
+	}{
+		{"uniform", NewUniformReservoirFloat64(100)},
+		{"exp decay", NewExpDecayReservoirFloat64(100, 0.015)},
+	}
+	for _, r := range reservoirs {
+		t.Run(r.name, func(t *testing.T) {
+			h := NewHistogramFloat64(r.reservoir)
+			for _, v := range []float64{0.5, 1.5, 2.5, 3.5, 4.5} {
+				h.Update(v)
+			}
+			s := h.Snapshot()
+			tests := []struct {
+				name      string
+				got, want float64
+			}{
+				{"Count", float64(s.Count()), 5},
+				{"Sum", s.Sum(), 12.5},
+				{"Min", s.Min(), 0.5},
+				{"Max", s.Max(), 4.5},
+				{"Mean", s.Mean(), 2.5},
+				{"StdDev", s.StdDev(), math.Sqrt(2.5)},
+				{"Value(0.5)", s.Value(0.5), 2.5},
+				{"Value(0.25)", s.Value(0.25), 1},
+			}
+			for _, tt := range tests {
+				if math.Abs(tt.got-tt.want) > 1e-9 {
+					t.Errorf("%s = %v,want %v", tt.name, tt.got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+//the count and sum of the histogram cover every value,not only the ones kept by the reservoir
+func TestHistogramFloat64TotalsBeyondReservoir(t *testing.T) {
+	h := NewHistogramFloat64(NewUniformReservoirFloat64(10))
+	for i := 0; i < 1000; i++ {
+		h.Update(0.25)
+	}
+	s := h.Snapshot()
+	if s.Count() != 1000 || s.Sum() != 250 {
+		t.Errorf("Count() = %d,Sum() = %v,want 1000 and 250", s.Count(), s.Sum())
+	}
+	if s.Size() != 10 {
+		t.Errorf("Size() = %d,want the 10 values of the reservoir", s.Size())
+	}
+}
+
+func TestSampleSnapshotFloat64Value(t *testing.T) {
+	s := NewSampleSnapshotFloat64(5, 15, []float64{5, 3, 1, 4, 2})
+	tests := []struct {
+		quantile float64
+		want     float64
+	}{
+		{0, 1},
+		{0.25, 1.5},
+		{0.5, 3},
+		{0.75, 4.5},
+		{1, 5},
+		{-1, 0},
+		{math.NaN(), 0},
+	}
+	for _, tt := range tests {
+		if got := s.Value(tt.quantile); got != tt.want {
+			t.Errorf("Value(%v) = %v,want %v", tt.quantile, got, tt.want)
+		}
+	}
+	if got := NewSampleSnapshotFloat64(0, 0, nil).Value(0.5); got != 0 {
+		t.Errorf("Value(0.5) of an empty snapshot = %v,want 0", got)
+	}
+}
+
+//the decaying reservoir keeps the exact float64 values,stored as bits in the int64 samples
+func TestExpDecayReservoirFloat64KeepsValues(t *testing.T) {
+	r := NewExpDecayReservoirFloat64(10, 0.015).(*ExpDecayReservoirFloat64)
+	now := time.Now()
+	for _, v := range []float64{-0.1, math.SmallestNonzeroFloat64, 1e300} {
+		r.UpdateBy(v, now)
+	}
+	values := r.Snapshot().Values()
+	want := []float64{-0.1, math.SmallestNonzeroFloat64, 1e300}
+	if len(values) != len(want) {
+		t.Fatalf("Values() = %v,want %v", values, want)
+	}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Errorf("Values() = %v,want %v", values, want)
+		}
+	}
+}
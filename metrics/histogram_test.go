@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//the count and the sum are the totals of the updates,kept apart from the values sampled by the reservoir
+func TestHistogramCountAndSum(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(values []int64) output.Snapshot
+	}{
+		{"uniform", func(values []int64) output.Snapshot {
+			h := NewHistogram(NewUniformReservoir(100))
+			for _, v := range values {
+				h.Update(v)
+			}
+			return h.Snapshot()
+		}},
+		{"exp decay", func(values []int64) output.Snapshot {
+			h := NewHistogram(NewExpDecayReservoir(100, DEFAULT_ALPHA))
+			for _, v := range values {
+				h.Update(v)
+			}
+			return h.Snapshot()
+		}},
+		{"timer", func(values []int64) output.Snapshot {
+			timer := NewTimer()
+			for _, v := range values {
+				timer.Update(time.Duration(v))
+			}
+			return timer.Snapshot()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.update([]int64{1, 2, 3, 10})
+			if s.Count() != 4 || s.Sum() != 16 || s.Mean() != 4 {
+				t.Errorf("Count() = %d,Sum() = %d,Mean() = %v,want 4,16 and 4", s.Count(), s.Sum(), s.Mean())
+			}
+			if s.Size() != 4 {
+				t.Errorf("Size() = %d,want the 4 values", s.Size())
+			}
+		})
+	}
+}
+
+//a reservoir smaller than the updates samples some of them,the count and the sum still cover all of them
+func TestHistogramTotalsBeyondReservoir(t *testing.T) {
+	h := NewHistogram(NewUniformReservoir(10))
+	for v := int64(1); v <= 1000; v++ {
+		h.Update(v)
+	}
+	s := h.Snapshot()
+	if s.Count() != 1000 || s.Sum() != 500500 {
+		t.Errorf("Count() = %d,Sum() = %d,want 1000 and 500500", s.Count(), s.Sum())
+	}
+	if s.Size() != 10 {
+		t.Errorf("Size() = %d,want the 10 values of the reservoir", s.Size())
+	}
+	if h.Count() != s.Count() || h.Sum() != s.Sum() {
+		t.Errorf("the histogram counts %d summing to %d,its snapshot %d summing to %d", h.Count(), h.Sum(), s.Count(), s.Sum())
+	}
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+)
+
+//lastUpdate records the time of the last update,it's embedded as the first member of the metrics to keep 64-bit alignment.
+//Updates are timestamped only while the metric is tracked,so that a metric nobody expires doesn't read the clock on every update
+type lastUpdate struct {
+	nanos    int64
+	tracking int64 //TrackUpdates(true) calls not matched by a TrackUpdates(false) yet,64-bit to keep the next member aligned
+}
+
+//TrackUpdates starts timestamping the updates for LastUpdate,or stops it once every tracker is gone,
+//see mechanism.UpdateTracker
+func (l *lastUpdate) TrackUpdates(track bool) {
+	if track {
+		atomic.AddInt64(&l.tracking, 1)
+	} else {
+		atomic.AddInt64(&l.tracking, -1)
+	}
+}
+
+func (l *lastUpdate) touch() {
+	l.touchAt(SystemClock)
+}
+
+//touchAt records the update at the time of the clock,if updates are tracked
+func (l *lastUpdate) touchAt(clock mechanism.Clock) {
+	if atomic.LoadInt64(&l.tracking) > 0 {
+		atomic.StoreInt64(&l.nanos, clock.Now().UnixNano())
+	}
+}
+
+//LastUpdate returns the time of the last update while updates were tracked,the zero time if there is none
+func (l *lastUpdate) LastUpdate() time.Time {
+	nanos := atomic.LoadInt64(&l.nanos)
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
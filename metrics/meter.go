@@ -10,19 +10,28 @@ import (
 const TickInterval int64 = 5e9 //5s
 
 type StandardMeter struct {
+	lastUpdate
 	a1, a5, a15 mechanism.EWMA
 	startTime   time.Time //start time ,not updated when set
 	count       int64
 	lastTick    int64 //last time tick,update every time when tick happens
+	clock       mechanism.Clock
 }
 
 func NewMeter() mechanism.Meter {
+	return NewCustomMeter(SystemClock, time.Minute, 5*time.Minute, 15*time.Minute)
+}
+
+//NewCustomMeter creates a meter reading the clock,whose Rate1,Rate5 and Rate15 are the moving averages over the given windows
+func NewCustomMeter(clock mechanism.Clock, window1, window5, window15 time.Duration) mechanism.Meter {
+	now := clock.Now()
 	return &StandardMeter{
-		a1:        NewEWMA1(),
-		a5:        NewEWMA5(),
-		a15:       NewEWMA15(),
-		startTime: time.Now(),
-		lastTick:  int64(time.Now().Nanosecond()),
+		a1:        NewEWMA(window1),
+		a5:        NewEWMA(window5),
+		a15:       NewEWMA(window15),
+		startTime: now,
+		lastTick:  now.UnixNano(),
+		clock:     clock,
 	}
 }
 
@@ -45,7 +54,7 @@ func (meter *StandardMeter) RateMean() float64 {
 	if currentCount == 0 {
 		return 0.0
 	} else {
-		elapsed := time.Now().Sub(meter.startTime).Nanoseconds()
+		elapsed := meter.clock.Now().Sub(meter.startTime).Seconds()
 		return float64(currentCount) / elapsed
 	}
 }
@@ -55,6 +64,7 @@ func (meter *StandardMeter) Mark() {
 	meter.mark(1)
 }
 func (meter *StandardMeter) mark(val int64) {
+	meter.touchAt(meter.clock)
 	meter.tickIfNecessary()
 	atomic.AddInt64(&meter.count, val)
 	meter.a1.Update(val)
@@ -64,8 +74,8 @@ func (meter *StandardMeter) mark(val int64) {
 }
 
 func (m *StandardMeter) tickIfNecessary() {
-	old := m.lastTick
-	current := int64(time.Now().Nanosecond())
+	old := atomic.LoadInt64(&m.lastTick)
+	current := m.clock.Now().UnixNano()
 	age := current - old
 	if age > TickInterval {
 		newStick := current - age%TickInterval
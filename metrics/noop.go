@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//NoopCounter is a counter discarding every update,returned instead of the metrics a registry can't hold
+type NoopCounter struct{}
+
+func (NoopCounter) Count() int64 { return 0 }
+func (NoopCounter) Dec(int64)    {}
+func (NoopCounter) Inc(int64)    {}
+
+//NoopGauge is a gauge discarding every update
+type NoopGauge struct{}
+
+func (NoopGauge) Value() int64 { return 0 }
+func (NoopGauge) Update(int64) {}
+
+//NoopGauge64 is a float64 gauge discarding every update
+type NoopGauge64 struct{}
+
+func (NoopGauge64) Value() float64 { return 0 }
+func (NoopGauge64) Update(float64) {}
+
+//NoopHistogram is a histogram discarding every update
+type NoopHistogram struct{}
+
+func (NoopHistogram) Count() int64              { return 0 }
+func (NoopHistogram) Sum() int64                { return 0 }
+func (NoopHistogram) Update(int64)              {}
+func (NoopHistogram) Snapshot() output.Snapshot { return NewSampleSnapshot(0, 0, nil) }
+
+//NoopHistogramFloat64 is a float64 histogram discarding every update
+type NoopHistogramFloat64 struct{}
+
+func (NoopHistogramFloat64) Count() int64   { return 0 }
+func (NoopHistogramFloat64) Sum() float64   { return 0 }
+func (NoopHistogramFloat64) Update(float64) {}
+func (NoopHistogramFloat64) Snapshot() output.SnapshotFloat64 {
+	return NewSampleSnapshotFloat64(0, 0, nil)
+}
+
+//NoopMeter is a meter discarding every mark
+type NoopMeter struct{}
+
+func (NoopMeter) Count() int64      { return 0 }
+func (NoopMeter) Rate1() float64    { return 0 }
+func (NoopMeter) Rate5() float64    { return 0 }
+func (NoopMeter) Rate15() float64   { return 0 }
+func (NoopMeter) RateMean() float64 { return 0 }
+func (NoopMeter) Mark()             {}
+
+//NoopTimer is a timer discarding every update,the timed functions are still run
+type NoopTimer struct{}
+
+func (NoopTimer) Count() int64              { return 0 }
+func (NoopTimer) Sum() int64                { return 0 }
+func (NoopTimer) Rate1() float64            { return 0 }
+func (NoopTimer) Rate5() float64            { return 0 }
+func (NoopTimer) Rate15() float64           { return 0 }
+func (NoopTimer) RateMean() float64         { return 0 }
+func (NoopTimer) Snapshot() output.Snapshot { return NewSampleSnapshot(0, 0, nil) }
+func (NoopTimer) Time(f func())             { f() }
+func (NoopTimer) Update(time.Duration)      {}
+
+//NoopHealthcheck is a healthcheck never checked,it's always healthy
+type NoopHealthcheck struct{}
+
+func (NoopHealthcheck) Check()          {}
+func (NoopHealthcheck) Error() error    { return nil }
+func (NoopHealthcheck) Healthy()        {}
+func (NoopHealthcheck) Unhealthy(error) {}
@@ -8,6 +8,7 @@ import (
 
 	"math/rand"
 
+	"github.com/carbin-gun/awesome-metrics/mechanism"
 	"github.com/carbin-gun/awesome-metrics/output"
 	"github.com/carbin-gun/skiplist"
 )
@@ -31,14 +32,21 @@ type ExpDecayReservoir struct {
 	mutex         sync.RWMutex
 	t0, t1        time.Time
 	values        *WeightedSampleStorage
+	clock         mechanism.Clock
 }
 
 func NewExpDecayReservoir(reservoirSize int64, alpha float64) Reservoir {
+	return NewExpDecayReservoirWithClock(reservoirSize, alpha, SystemClock)
+}
+
+//NewExpDecayReservoirWithClock is just like NewExpDecayReservoir,but it weights the samples by the time of the clock
+func NewExpDecayReservoirWithClock(reservoirSize int64, alpha float64, clock mechanism.Clock) Reservoir {
 	r := &ExpDecayReservoir{
 		alpha:         alpha,
 		reservoirSize: reservoirSize,
-		t0:            time.Now(),
+		t0:            clock.Now(),
 		values:        &WeightedSampleStorage{store: skiplist.NewList()},
+		clock:         clock,
 	}
 	r.t1 = r.t0.Add(RescaleThreshold)
 	return r
@@ -55,7 +63,7 @@ func (r *ExpDecayReservoir) Size() int64 {
 }
 
 func (r *ExpDecayReservoir) Update(val int64) {
-	r.UpdateBy(val, time.Now())
+	r.UpdateBy(val, r.clock.Now())
 }
 func (r *ExpDecayReservoir) UpdateBy(val int64, t time.Time) {
 	r.rescaleIfNeeded(t)
@@ -70,7 +78,7 @@ func (r *ExpDecayReservoir) UpdateBy(val int64, t time.Time) {
 	} else {
 		k, _ := r.values.First()
 		if float64(k) < priority && r.values.Insert(priority, sample.MarshalBytes()) != nil {
-			for r.values.Delete(k) {
+			for !r.values.Delete(k) {
 				k, _ = r.values.First()
 			}
 		}
@@ -85,38 +93,36 @@ func (r *ExpDecayReservoir) rescaleIfNeeded(t time.Time) {
 		scalingFactor := math.Exp(-r.alpha * (r.t0.Sub(t0).Seconds()))
 		r.t1 = r.t0.Add(RescaleThreshold)
 		iterator := r.values.Iterator()
-		if iterator.Next() {
-			key := iterator.Key()
-			r.values.Delete(key)
-			sample := UnMarshalFromBytes(iterator.Val())
-			newVal := WeightedSample{weight: sample.weight * scalingFactor, value: sample.value}
+		var keys []float64
+		var samples []WeightedSample
+		for iterator.Next() {
+			keys = append(keys, iterator.Key())
+			samples = append(samples, UnMarshalFromBytes(iterator.Val()))
+		}
+		r.values.Clear()
+		for i, key := range keys {
+			newVal := WeightedSample{weight: samples[i].weight * scalingFactor, value: samples[i].value}
 			r.values.Insert(key*scalingFactor, newVal.MarshalBytes())
 		}
 	}
 }
 
 func (r *ExpDecayReservoir) Snapshot() output.Snapshot {
-
+	values := r.duplicateVals()
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return NewSampleSnapshot(int64(len(values)), sum, values)
 }
 
-func (r *ExpDecayReservoir) duplicateVals() {
+func (r *ExpDecayReservoir) duplicateVals() []int64 {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	iterator := r.values.Iterator()
-	var samples []WeightedSample
-	if iterator.Next() {
-		value := UnMarshalFromBytes(iterator.Val())
-		samples = append(samples, value)
+	var values []int64
+	for iterator.Next() {
+		values = append(values, UnMarshalFromBytes(iterator.Val()).value)
 	}
-	var sumWeight int64
-	values := make(int64, len(samples))
-	for i := 0; i < len(samples); i++ {
-		sumWeight += samples[i].weight
-	}
-	for i := 0; i < len(samples); i++ {
-		values = append(values,samples[i].value)
-	}
-
-
-
+	return values
 }
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+type ReservoirFloat64 interface {
+	Size() int64
+	Update(val float64)
+	Snapshot() output.SnapshotFloat64
+}
+
+//ExpDecayReservoirFloat64 is an ExpDecayReservoir whose samples are the bits of float64 values
+type ExpDecayReservoirFloat64 struct {
+	reservoir *ExpDecayReservoir
+}
+
+func NewExpDecayReservoirFloat64(reservoirSize int64, alpha float64) ReservoirFloat64 {
+	return NewExpDecayReservoirFloat64WithClock(reservoirSize, alpha, SystemClock)
+}
+
+//NewExpDecayReservoirFloat64WithClock is just like NewExpDecayReservoirFloat64,but it weights the samples by the time of the clock
+func NewExpDecayReservoirFloat64WithClock(reservoirSize int64, alpha float64, clock mechanism.Clock) ReservoirFloat64 {
+	return &ExpDecayReservoirFloat64{reservoir: NewExpDecayReservoirWithClock(reservoirSize, alpha, clock).(*ExpDecayReservoir)}
+}
+
+func (r *ExpDecayReservoirFloat64) Size() int64 {
+	return r.reservoir.Size()
+}
+
+func (r *ExpDecayReservoirFloat64) Update(val float64) {
+	r.UpdateBy(val, r.reservoir.clock.Now())
+}
+
+func (r *ExpDecayReservoirFloat64) UpdateBy(val float64, t time.Time) {
+	r.reservoir.UpdateBy(int64(math.Float64bits(val)), t)
+}
+
+func (r *ExpDecayReservoirFloat64) Snapshot() output.SnapshotFloat64 {
+	bits := r.reservoir.duplicateVals()
+	values := make([]float64, len(bits))
+	var sum float64
+	for i, b := range bits {
+		values[i] = math.Float64frombits(uint64(b))
+		sum += values[i]
+	}
+	return NewSampleSnapshotFloat64(int64(len(values)), sum, values)
+}
+
+//UniformReservoirFloat64 keeps a uniform random sample of all the values,using Vitter's algorithm R
+type UniformReservoirFloat64 struct {
+	count         int64
+	reservoirSize int64
+	mutex         sync.Mutex
+	values        []float64
+}
+
+func NewUniformReservoirFloat64(reservoirSize int64) ReservoirFloat64 {
+	return &UniformReservoirFloat64{
+		reservoirSize: reservoirSize,
+		values:        make([]float64, 0, reservoirSize),
+	}
+}
+
+func (r *UniformReservoirFloat64) Size() int64 {
+	count := atomic.LoadInt64(&r.count)
+	if count < r.reservoirSize {
+		return count
+	}
+	return r.reservoirSize
+}
+
+func (r *UniformReservoirFloat64) Update(val float64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	count := atomic.AddInt64(&r.count, 1)
+	if count <= r.reservoirSize {
+		r.values = append(r.values, val)
+	} else if i := rand.Int63n(count); i < r.reservoirSize {
+		r.values[i] = val
+	}
+}
+
+func (r *UniformReservoirFloat64) Snapshot() output.SnapshotFloat64 {
+	r.mutex.Lock()
+	values := make([]float64, len(r.values))
+	copy(values, r.values)
+	r.mutex.Unlock()
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return NewSampleSnapshotFloat64(int64(len(values)), sum, values)
+}
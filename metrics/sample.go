@@ -1,7 +1,8 @@
 package metrics
 
 import (
-	"encoding/json"
+	"encoding/binary"
+	"math"
 
 	"github.com/carbin-gun/skiplist"
 )
@@ -11,14 +12,21 @@ type WeightedSample struct {
 	value  int64
 }
 
+//MarshalBytes encodes the sample as 16 big-endian bytes,weight first
 func (s WeightedSample) MarshalBytes() []byte {
-	v, _ := json.Marshal(s)
-	return v
+	bytes := make([]byte, 16)
+	binary.BigEndian.PutUint64(bytes[:8], math.Float64bits(s.weight))
+	binary.BigEndian.PutUint64(bytes[8:], uint64(s.value))
+	return bytes
 }
 func UnMarshalFromBytes(bytes []byte) WeightedSample {
-	var val WeightedSample
-	json.Unmarshal(bytes, &val)
-	return val
+	if len(bytes) < 16 {
+		return WeightedSample{}
+	}
+	return WeightedSample{
+		weight: math.Float64frombits(binary.BigEndian.Uint64(bytes[:8])),
+		value:  int64(binary.BigEndian.Uint64(bytes[8:])),
+	}
 }
 
 type WeightedSampleStorage struct {
@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//SampleSnapshot is a read-only copy of the values held by a reservoir,sorted ascending
+type SampleSnapshot struct {
+	count  int64
+	sum    int64
+	values []int64
+}
+
+//NewSampleSnapshot copies and sorts the given values.count and sum describe all the recorded values,not only the sampled ones
+func NewSampleSnapshot(count int64, sum int64, values []int64) output.Snapshot {
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &SampleSnapshot{count: count, sum: sum, values: sorted}
+}
+
+func (s *SampleSnapshot) Count() int64 {
+	return s.count
+}
+
+func (s *SampleSnapshot) Sum() int64 {
+	return s.sum
+}
+
+//Value returns the value at the given quantile,which should be in [0,1]
+func (s *SampleSnapshot) Value(quantile float64) float64 {
+	if quantile < 0.0 || quantile > 1.0 || math.IsNaN(quantile) {
+		return 0
+	}
+	size := len(s.values)
+	if size == 0 {
+		return 0
+	}
+	pos := quantile * float64(size+1)
+	if pos < 1 {
+		return float64(s.values[0])
+	}
+	if pos >= float64(size) {
+		return float64(s.values[size-1])
+	}
+	lower := float64(s.values[int(pos)-1])
+	upper := float64(s.values[int(pos)])
+	return lower + (pos-math.Floor(pos))*(upper-lower)
+}
+
+func (s *SampleSnapshot) Values() []float64 {
+	values := make([]float64, len(s.values))
+	for i, v := range s.values {
+		values[i] = float64(v)
+	}
+	return values
+}
+
+func (s *SampleSnapshot) Size() int64 {
+	return int64(len(s.values))
+}
+
+func (s *SampleSnapshot) Max() int64 {
+	if len(s.values) == 0 {
+		return 0
+	}
+	return s.values[len(s.values)-1]
+}
+
+func (s *SampleSnapshot) Min() int64 {
+	if len(s.values) == 0 {
+		return 0
+	}
+	return s.values[0]
+}
+
+func (s *SampleSnapshot) Mean() float64 {
+	if len(s.values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range s.values {
+		sum += float64(v)
+	}
+	return sum / float64(len(s.values))
+}
+
+func (s *SampleSnapshot) StdDev() float64 {
+	if len(s.values) <= 1 {
+		return 0
+	}
+	mean := s.Mean()
+	var sum float64
+	for _, v := range s.values {
+		diff := float64(v) - mean
+		sum += diff * diff
+	}
+	return math.Sqrt(sum / float64(len(s.values)-1))
+}
+
+func (s *SampleSnapshot) Median() float64 {
+	return s.Value(0.5)
+}
+func (s *SampleSnapshot) Get75thPercentile() float64 {
+	return s.Value(0.75)
+}
+func (s *SampleSnapshot) Get95thPercentile() float64 {
+	return s.Value(0.95)
+}
+func (s *SampleSnapshot) Get98thPercentile() float64 {
+	return s.Value(0.98)
+}
+func (s *SampleSnapshot) Get99thPercentile() float64 {
+	return s.Value(0.99)
+}
+func (s *SampleSnapshot) Get999thPercentile() float64 {
+	return s.Value(0.999)
+}
+
+//Percentiles returns the values at output.DefaultPercentiles
+func (s *SampleSnapshot) Percentiles() []float64 {
+	values := make([]float64, len(output.DefaultPercentiles))
+	for i, p := range output.DefaultPercentiles {
+		values[i] = s.Value(p)
+	}
+	return values
+}
+
+//totalsSnapshot overrides the count and sum of a reservoir snapshot with the totals of its histogram
+type totalsSnapshot struct {
+	output.Snapshot
+	count int64
+	sum   int64
+}
+
+func (s *totalsSnapshot) Count() int64 {
+	return s.count
+}
+
+func (s *totalsSnapshot) Sum() int64 {
+	return s.sum
+}
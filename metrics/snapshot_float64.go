@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//SampleSnapshotFloat64 is a read-only copy of the float64 values held by a reservoir,sorted ascending
+type SampleSnapshotFloat64 struct {
+	count  int64
+	sum    float64
+	values []float64
+}
+
+//NewSampleSnapshotFloat64 copies and sorts the given values.count and sum describe all the recorded values,not only the sampled ones
+func NewSampleSnapshotFloat64(count int64, sum float64, values []float64) output.SnapshotFloat64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return &SampleSnapshotFloat64{count: count, sum: sum, values: sorted}
+}
+
+func (s *SampleSnapshotFloat64) Count() int64 {
+	return s.count
+}
+
+func (s *SampleSnapshotFloat64) Sum() float64 {
+	return s.sum
+}
+
+//Value returns the value at the given quantile,which should be in [0,1]
+func (s *SampleSnapshotFloat64) Value(quantile float64) float64 {
+	if quantile < 0.0 || quantile > 1.0 || math.IsNaN(quantile) {
+		return 0
+	}
+	size := len(s.values)
+	if size == 0 {
+		return 0
+	}
+	pos := quantile * float64(size+1)
+	if pos < 1 {
+		return s.values[0]
+	}
+	if pos >= float64(size) {
+		return s.values[size-1]
+	}
+	lower := s.values[int(pos)-1]
+	upper := s.values[int(pos)]
+	return lower + (pos-math.Floor(pos))*(upper-lower)
+}
+
+func (s *SampleSnapshotFloat64) Values() []float64 {
+	values := make([]float64, len(s.values))
+	copy(values, s.values)
+	return values
+}
+
+func (s *SampleSnapshotFloat64) Size() int64 {
+	return int64(len(s.values))
+}
+
+func (s *SampleSnapshotFloat64) Max() float64 {
+	if len(s.values) == 0 {
+		return 0
+	}
+	return s.values[len(s.values)-1]
+}
+
+func (s *SampleSnapshotFloat64) Min() float64 {
+	if len(s.values) == 0 {
+		return 0
+	}
+	return s.values[0]
+}
+
+func (s *SampleSnapshotFloat64) Mean() float64 {
+	if len(s.values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range s.values {
+		sum += v
+	}
+	return sum / float64(len(s.values))
+}
+
+func (s *SampleSnapshotFloat64) StdDev() float64 {
+	if len(s.values) <= 1 {
+		return 0
+	}
+	mean := s.Mean()
+	var sum float64
+	for _, v := range s.values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(s.values)-1))
+}
+
+//Percentiles returns the values at output.DefaultPercentiles
+func (s *SampleSnapshotFloat64) Percentiles() []float64 {
+	values := make([]float64, len(output.DefaultPercentiles))
+	for i, p := range output.DefaultPercentiles {
+		values[i] = s.Value(p)
+	}
+	return values
+}
+
+//totalsSnapshotFloat64 overrides the count and sum of a reservoir snapshot with the totals of its histogram
+type totalsSnapshotFloat64 struct {
+	output.SnapshotFloat64
+	count int64
+	sum   float64
+}
+
+func (s *totalsSnapshotFloat64) Count() int64 {
+	return s.count
+}
+
+func (s *totalsSnapshotFloat64) Sum() float64 {
+	return s.sum
+}
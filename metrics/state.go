@@ -0,0 +1,233 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+)
+
+//the persisted states of the standard metrics,see mechanism.Persistent
+type counterState struct {
+	Count int64 `json:"count"`
+}
+
+type gaugeState struct {
+	Value int64 `json:"value"`
+}
+
+type gauge64State struct {
+	Value float64 `json:"value"`
+}
+
+type meterState struct {
+	Count     int64     `json:"count"`
+	StartTime time.Time `json:"start_time"`
+}
+
+type histogramState struct {
+	Count   int64   `json:"count"`
+	Sum     int64   `json:"sum"`
+	Samples []int64 `json:"samples,omitempty"`
+}
+
+type histogramFloat64State struct {
+	Count   int64     `json:"count"`
+	Sum     float64   `json:"sum"`
+	Samples []float64 `json:"samples,omitempty"`
+}
+
+type bucketHistogramState struct {
+	Count  int64     `json:"count"`
+	Sum    int64     `json:"sum"`
+	Min    int64     `json:"min"`
+	Max    int64     `json:"max"`
+	Bounds []float64 `json:"bounds"`
+	Counts []int64   `json:"counts"`
+}
+
+type timerState struct {
+	Histogram json.RawMessage `json:"histogram,omitempty"`
+	Meter     json.RawMessage `json:"meter,omitempty"`
+}
+
+func (c *StandardCounter) SaveState(bool) ([]byte, error) {
+	return json.Marshal(counterState{Count: c.Count()})
+}
+
+func (c *StandardCounter) RestoreState(state []byte) error {
+	var s counterState
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+	atomic.StoreInt64(&c.count, s.Count)
+	return nil
+}
+
+func (g *StandardGauge) SaveState(bool) ([]byte, error) {
+	return json.Marshal(gaugeState{Value: g.Value()})
+}
+
+func (g *StandardGauge) RestoreState(state []byte) error {
+	var s gaugeState
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+	atomic.StoreInt64(&g.value, s.Value)
+	return nil
+}
+
+func (g *StandardGauge64) SaveState(bool) ([]byte, error) {
+	return json.Marshal(gauge64State{Value: g.Value()})
+}
+
+func (g *StandardGauge64) RestoreState(state []byte) error {
+	var s gauge64State
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.value = s.Value
+	return nil
+}
+
+//SaveState keeps the count and the start time,the moving averages start over after a restore
+func (meter *StandardMeter) SaveState(bool) ([]byte, error) {
+	return json.Marshal(meterState{Count: meter.Count(), StartTime: meter.startTime})
+}
+
+//RestoreState should be called before the meter is used,the start time isn't updated atomically
+func (meter *StandardMeter) RestoreState(state []byte) error {
+	var s meterState
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+	atomic.StoreInt64(&meter.count, s.Count)
+	if !s.StartTime.IsZero() {
+		meter.startTime = s.StartTime
+	}
+	return nil
+}
+
+func (histogram *StandardHistogram) SaveState(samples bool) ([]byte, error) {
+	s := histogramState{Count: histogram.Count(), Sum: histogram.Sum()}
+	if samples {
+		for _, v := range histogram.reservoir.Snapshot().Values() {
+			s.Samples = append(s.Samples, int64(v))
+		}
+	}
+	return json.Marshal(s)
+}
+
+//RestoreState puts the saved samples back into the reservoir as if they were updated now
+func (histogram *StandardHistogram) RestoreState(state []byte) error {
+	var s histogramState
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+	for _, v := range s.Samples {
+		histogram.reservoir.Update(v)
+	}
+	atomic.StoreInt64(&histogram.count, s.Count)
+	atomic.StoreInt64(&histogram.sum, s.Sum)
+	return nil
+}
+
+func (histogram *StandardHistogramFloat64) SaveState(samples bool) ([]byte, error) {
+	s := histogramFloat64State{Count: histogram.Count(), Sum: histogram.Sum()}
+	if samples {
+		s.Samples = histogram.reservoir.Snapshot().Values()
+	}
+	return json.Marshal(s)
+}
+
+func (histogram *StandardHistogramFloat64) RestoreState(state []byte) error {
+	var s histogramFloat64State
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+	for _, v := range s.Samples {
+		histogram.reservoir.Update(v)
+	}
+	atomic.StoreInt64(&histogram.count, s.Count)
+	atomic.StoreUint64(&histogram.sumBits, math.Float64bits(s.Sum))
+	return nil
+}
+
+//SaveState always keeps the bucket counts,they are small
+func (h *BucketHistogram) SaveState(bool) ([]byte, error) {
+	s := bucketHistogramState{
+		Count:  h.Count(),
+		Sum:    h.Sum(),
+		Min:    atomic.LoadInt64(&h.min),
+		Max:    atomic.LoadInt64(&h.max),
+		Bounds: h.bounds,
+		Counts: make([]int64, len(h.counts)),
+	}
+	for i := range h.counts {
+		s.Counts[i] = atomic.LoadInt64(&h.counts[i])
+	}
+	return json.Marshal(s)
+}
+
+//RestoreState fails if the histogram doesn't have the saved bounds
+func (h *BucketHistogram) RestoreState(state []byte) error {
+	var s bucketHistogramState
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+	if len(s.Bounds) != len(h.bounds) || len(s.Counts) != len(h.counts) {
+		return fmt.Errorf("bucket histogram has %d bounds,the saved state has %d", len(h.bounds), len(s.Bounds))
+	}
+	for i, b := range s.Bounds {
+		if b != h.bounds[i] {
+			return fmt.Errorf("bucket histogram bound %d is %v,the saved one is %v", i, h.bounds[i], b)
+		}
+	}
+	for i, c := range s.Counts {
+		atomic.StoreInt64(&h.counts[i], c)
+	}
+	atomic.StoreInt64(&h.count, s.Count)
+	atomic.StoreInt64(&h.sum, s.Sum)
+	atomic.StoreInt64(&h.min, s.Min)
+	atomic.StoreInt64(&h.max, s.Max)
+	return nil
+}
+
+func (timer *StandardTimer) SaveState(samples bool) ([]byte, error) {
+	var s timerState
+	var err error
+	if p, ok := timer.histogram.(mechanism.Persistent); ok {
+		if s.Histogram, err = p.SaveState(samples); err != nil {
+			return nil, err
+		}
+	}
+	if p, ok := timer.meter.(mechanism.Persistent); ok {
+		if s.Meter, err = p.SaveState(samples); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(s)
+}
+
+func (timer *StandardTimer) RestoreState(state []byte) error {
+	var s timerState
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+	if p, ok := timer.histogram.(mechanism.Persistent); ok && len(s.Histogram) > 0 {
+		if err := p.RestoreState(s.Histogram); err != nil {
+			return err
+		}
+	}
+	if p, ok := timer.meter.(mechanism.Persistent); ok && len(s.Meter) > 0 {
+		if err := p.RestoreState(s.Meter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
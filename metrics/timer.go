@@ -10,21 +10,25 @@ import (
 type StandardTimer struct {
 	histogram mechanism.Histogram
 	meter     mechanism.Meter
+	clock     mechanism.Clock
 }
 
 //NewTimer return the default timer
 func NewTimer() mechanism.Timer {
-	return &StandardTimer{
-		histogram: NewHistogram(NewExpDecayReservoir(DEFAULT_RESERVOIR_SIZE, DEFAULT_ALPHA)),
-		meter:     NewMeter(),
-	}
+	return CustomNewTimer(NewHistogram(NewExpDecayReservoir(DEFAULT_RESERVOIR_SIZE, DEFAULT_ALPHA)), NewMeter())
 }
 
 //CustomNewTimer with user specified histogram & meter
 func CustomNewTimer(histogram mechanism.Histogram, meter mechanism.Meter) mechanism.Timer {
+	return NewTimerWithClock(histogram, meter, SystemClock)
+}
+
+//NewTimerWithClock is just like CustomNewTimer,but Time measures the durations with the clock
+func NewTimerWithClock(histogram mechanism.Histogram, meter mechanism.Meter, clock mechanism.Clock) mechanism.Timer {
 	return &StandardTimer{
 		histogram: histogram,
 		meter:     meter,
+		clock:     clock,
 	}
 }
 
@@ -32,6 +36,9 @@ func (timer *StandardTimer) Count() int64 {
 	return timer.histogram.Count()
 
 }
+func (timer *StandardTimer) Sum() int64 {
+	return timer.histogram.Sum()
+}
 func (timer *StandardTimer) Rate1() float64 {
 	return timer.meter.Rate1()
 }
@@ -52,9 +59,31 @@ func (timer *StandardTimer) Snapshot() output.Snapshot {
 	return timer.histogram.Snapshot()
 }
 func (timer *StandardTimer) Time(f func()) {
-	ts := time.Now()
+	ts := timer.clock.Now()
 	f()
-	timer.Update(time.Since(ts))
+	timer.Update(timer.clock.Now().Sub(ts))
+}
+
+//LastUpdate is the last update recorded by the meter,which reads the clock of the timer,
+//or by the histogram if the meter doesn't record it,the zero time if neither does
+func (timer *StandardTimer) LastUpdate() time.Time {
+	for _, m := range []interface{}{timer.meter, timer.histogram} {
+		if e, ok := m.(mechanism.Expirable); ok {
+			if last := e.LastUpdate(); !last.IsZero() {
+				return last
+			}
+		}
+	}
+	return time.Time{}
+}
+
+//TrackUpdates tracks the updates of the meter and of the histogram,see mechanism.UpdateTracker
+func (timer *StandardTimer) TrackUpdates(track bool) {
+	for _, m := range []interface{}{timer.meter, timer.histogram} {
+		if t, ok := m.(mechanism.UpdateTracker); ok {
+			t.TrackUpdates(track)
+		}
+	}
 }
 func (timer *StandardTimer) Update(duration time.Duration) {
 	timer.histogram.Update(int64(duration))
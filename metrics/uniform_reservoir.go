@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//UniformReservoir keeps a uniform random sample of all the values,using Vitter's algorithm R
+type UniformReservoir struct {
+	count         int64
+	reservoirSize int64
+	mutex         sync.Mutex
+	values        []int64
+}
+
+func NewUniformReservoir(reservoirSize int64) Reservoir {
+	return &UniformReservoir{
+		reservoirSize: reservoirSize,
+		values:        make([]int64, 0, reservoirSize),
+	}
+}
+
+func (r *UniformReservoir) Size() int64 {
+	count := atomic.LoadInt64(&r.count)
+	if count < r.reservoirSize {
+		return count
+	}
+	return r.reservoirSize
+}
+
+func (r *UniformReservoir) Update(val int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	count := atomic.AddInt64(&r.count, 1)
+	if count <= r.reservoirSize {
+		r.values = append(r.values, val)
+	} else if i := rand.Int63n(count); i < r.reservoirSize {
+		r.values[i] = val
+	}
+}
+
+func (r *UniformReservoir) Snapshot() output.Snapshot {
+	r.mutex.Lock()
+	values := make([]int64, len(r.values))
+	copy(values, r.values)
+	r.mutex.Unlock()
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return NewSampleSnapshot(int64(len(values)), sum, values)
+}
+
+func (r *UniformReservoir) EstimatedBytes() int64 {
+	return metricOverheadBytes + r.reservoirSize*uniformSampleBytes
+}
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+func TestHistogramFloat64(t *testing.T) {
+	r := NewRegistry()
+	h := r.HistogramFloat64("ratio", WithReservoir(ReservoirUniform, 10))
+	if r.HistogramFloat64("ratio") != h {
+		t.Fatal("the registered histogram isn't returned")
+	}
+	if _, ok := h.(*metrics.StandardHistogramFloat64); !ok {
+		t.Fatalf("HistogramFloat64() = %T,want a *metrics.StandardHistogramFloat64", h)
+	}
+	h.Update(0.5)
+	if s := h.Snapshot(); s.Count() != 1 || s.Sum() != 0.5 {
+		t.Errorf("Count() = %d,Sum() = %v,want 1 and 0.5", s.Count(), s.Sum())
+	}
+}
+
+//a clock moved by hand,moved by step on every reading if step is set
+type testClock struct {
+	mutex sync.Mutex
+	now   time.Time
+	step  time.Duration
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestWithReservoir(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		size int64
+	}{
+		{"default", nil, 10},
+		{"uniform", []Option{WithReservoir(ReservoirUniform, 2)}, 2},
+		{"exp decay", []Option{WithReservoir(ReservoirExpDecay, 3)}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			h, timer := r.Histogram("h", tt.opts...), r.Timer("t", tt.opts...)
+			for i := int64(1); i <= 10; i++ {
+				h.Update(i)
+				timer.Update(time.Duration(i))
+			}
+			if size := h.Snapshot().Size(); size != tt.size {
+				t.Errorf("histogram Size() = %d,want %d", size, tt.size)
+			}
+			if size := timer.Snapshot().Size(); size != tt.size {
+				t.Errorf("timer Size() = %d,want %d", size, tt.size)
+			}
+		})
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	r := NewRegistry()
+	timer := r.Timer("t", WithClock(&testClock{now: time.Unix(1000, 0), step: time.Second}))
+	timer.Time(func() {})
+	if max := timer.Snapshot().Max(); max != int64(time.Second) {
+		t.Errorf("Time() recorded %v,want the step of the clock", time.Duration(max))
+	}
+	clock := &testClock{now: time.Unix(1000, 0)}
+	m := r.Meter("m", WithClock(clock))
+	clock.Add(10 * time.Second)
+	for i := 0; i < 5; i++ {
+		m.Mark()
+	}
+	if rate := m.RateMean(); rate != 0.5 {
+		t.Errorf("RateMean() = %v,want 5 marks in 10s of the clock", rate)
+	}
+}
+
+func TestWithEWMAWindows(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		equal bool
+	}{
+		{"default", nil, false},
+		{"same windows", []Option{WithEWMAWindows(time.Minute, time.Minute, time.Minute)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{now: time.Unix(1000, 0)}
+			m := NewRegistry().Meter("m", append([]Option{WithClock(clock)}, tt.opts...)...)
+			//the first tick starts every average at the same rate,the second one moves them by their windows
+			for i := 0; i < 10; i++ {
+				m.Mark()
+			}
+			clock.Add(6 * time.Second)
+			m.Mark()
+			clock.Add(5 * time.Second)
+			m.Mark()
+			if equal := m.Rate1() == m.Rate15(); equal != tt.equal || m.Rate1() == 0 {
+				t.Errorf("Rate1() = %v,Rate15() = %v,want equal %v", m.Rate1(), m.Rate15(), tt.equal)
+			}
+		})
+	}
+}
+
+func TestWrapperConstructors(t *testing.T) {
+	r := NewRegistry()
+	unhealthy := errors.New("down")
+	tests := []struct {
+		name  string
+		get   func() interface{}
+		check func(t *testing.T, m interface{})
+	}{
+		{"gauge", func() interface{} { return r.Gauge("gauge") }, func(t *testing.T, m interface{}) {
+			g := m.(mechanism.Gauge)
+			g.Update(3)
+			if g.Value() != 3 {
+				t.Errorf("Value() = %d,want 3", g.Value())
+			}
+		}},
+		{"gauge64", func() interface{} { return r.Gauge64("gauge64") }, func(t *testing.T, m interface{}) {
+			g := m.(mechanism.Gauge64)
+			g.Update(0.5)
+			if g.Value() != 0.5 {
+				t.Errorf("Value() = %v,want 0.5", g.Value())
+			}
+		}},
+		{"healthcheck", func() interface{} {
+			return r.Healthcheck("healthcheck", func(h mechanism.Healthcheck) { h.Unhealthy(unhealthy) })
+		}, func(t *testing.T, m interface{}) {
+			h := m.(mechanism.Healthcheck)
+			h.Check()
+			if h.Error() != unhealthy {
+				t.Errorf("Error() = %v,want the error of the check", h.Error())
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.get()
+			if tt.get() != m || r.Registry.Get(tt.name) != m {
+				t.Fatal("the registered metric isn't returned")
+			}
+			tt.check(t, m)
+		})
+	}
+}
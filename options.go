@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+//ReservoirKind selects how timers and histograms sample their values
+type ReservoirKind int
+
+const (
+	ReservoirExpDecay ReservoirKind = iota //exponentially decaying,biased towards the last 5 minutes
+	ReservoirUniform                       //uniform over the whole life of the metric
+)
+
+//Option configures the metrics created by the RegistryWrapper,the ones not applying to a kind of metric are ignored
+type Option func(*metricConfig)
+
+type metricConfig struct {
+	reservoir     ReservoirKind
+	reservoirSize int64
+	alpha         float64
+	buckets       []float64
+	clock         mechanism.Clock
+	windows       [3]time.Duration
+	metricOptions []registry.MetricOption
+}
+
+func newMetricConfig(opts ...[]Option) *metricConfig {
+	c := &metricConfig{
+		reservoir:     ReservoirExpDecay,
+		reservoirSize: metrics.DEFAULT_RESERVOIR_SIZE,
+		alpha:         metrics.DEFAULT_ALPHA,
+		clock:         metrics.SystemClock,
+		windows:       [3]time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute},
+	}
+	for _, list := range opts {
+		for _, opt := range list {
+			opt(c)
+		}
+	}
+	return c
+}
+
+//WithReservoir samples the values of timers and histograms into a reservoir of the kind holding size values
+func WithReservoir(kind ReservoirKind, size int64) Option {
+	return func(c *metricConfig) {
+		c.reservoir = kind
+		c.reservoirSize = size
+		c.buckets = nil
+	}
+}
+
+//WithBuckets counts the values of timers and histograms into buckets with the given upper bounds instead of sampling them,
+//such as metrics.ExponentialBuckets(1e6, 2, 20) for durations from 1ms
+func WithBuckets(bounds ...float64) Option {
+	return func(c *metricConfig) {
+		c.buckets = bounds
+	}
+}
+
+//WithClock sets the clock of the timers,meters and exponentially decaying reservoirs
+func WithClock(clock mechanism.Clock) Option {
+	return func(c *metricConfig) {
+		c.clock = clock
+	}
+}
+
+//WithEWMAWindows sets the windows of the moving averages reported as Rate1,Rate5 and Rate15 by meters and timers
+func WithEWMAWindows(window1, window5, window15 time.Duration) Option {
+	return func(c *metricConfig) {
+		c.windows = [3]time.Duration{window1, window5, window15}
+	}
+}
+
+//WithDescription describes what the metric measures
+func WithDescription(description string) Option {
+	return func(c *metricConfig) {
+		c.metricOptions = append(c.metricOptions, registry.WithDescription(description))
+	}
+}
+
+//WithUnit sets the unit of the values of the metric,such as bytes
+func WithUnit(unit string) Option {
+	return func(c *metricConfig) {
+		c.metricOptions = append(c.metricOptions, registry.WithUnit(unit))
+	}
+}
+
+//WithMonotonic declares that the count of the metric only goes up,so that reporters can expose it as a counter
+func WithMonotonic() Option {
+	return func(c *metricConfig) {
+		c.metricOptions = append(c.metricOptions, registry.WithMonotonic())
+	}
+}
+
+//WithPercentiles sets the percentiles reported for the metric,overriding the reporter ones
+func WithPercentiles(percentiles ...float64) Option {
+	return func(c *metricConfig) {
+		c.metricOptions = append(c.metricOptions, registry.WithPercentiles(percentiles...))
+	}
+}
+
+func (c *metricConfig) newHistogram() mechanism.Histogram {
+	if c.buckets != nil {
+		return metrics.NewBucketHistogram(c.buckets)
+	}
+	switch c.reservoir {
+	case ReservoirUniform:
+		return metrics.NewHistogram(metrics.NewUniformReservoir(c.reservoirSize))
+	}
+	return metrics.NewHistogram(metrics.NewExpDecayReservoirWithClock(c.reservoirSize, c.alpha, c.clock))
+}
+
+//newHistogramFloat64 samples into the reservoir of the config,buckets don't apply to float64 histograms
+func (c *metricConfig) newHistogramFloat64() mechanism.HistogramFloat64 {
+	switch c.reservoir {
+	case ReservoirUniform:
+		return metrics.NewHistogramFloat64(metrics.NewUniformReservoirFloat64(c.reservoirSize))
+	}
+	return metrics.NewHistogramFloat64(metrics.NewExpDecayReservoirFloat64WithClock(c.reservoirSize, c.alpha, c.clock))
+}
+
+func (c *metricConfig) newMeter() mechanism.Meter {
+	return metrics.NewCustomMeter(c.clock, c.windows[0], c.windows[1], c.windows[2])
+}
+
+func (c *metricConfig) newTimer() mechanism.Timer {
+	return metrics.NewTimerWithClock(c.newHistogram(), c.newMeter(), c.clock)
+}
+
+//defaults holds the options applied before the given ones,per kind of metric
+type defaults struct {
+	mutex   sync.RWMutex
+	options map[registry.MetricKind][]Option
+}
+
+func newDefaults() *defaults {
+	return &defaults{options: make(map[registry.MetricKind][]Option)}
+}
+
+func (d *defaults) get(kind registry.MetricKind) []Option {
+	if d == nil {
+		return nil
+	}
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.options[kind]
+}
+
+func (d *defaults) set(kind registry.MetricKind, opts []Option) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.options[kind] = opts
+}
@@ -0,0 +1,61 @@
+package output
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+//Logger receives the events of registries and reporters,such as registration conflicts and report failures.
+//The args are alternating keys and values,such as "name", "db.latency", "error", err.
+//A *slog.Logger is a Logger.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+//NopLogger discards every event,it is the Logger of the registries and reporters which have none
+var NopLogger Logger = nopLogger{}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+//StdLogger adapts a *log.Logger,events are printed as "LEVEL msg key=value ...".Debug events are dropped unless debug is true.
+func StdLogger(l *log.Logger, debug bool) Logger {
+	return stdLogger{logger: l, debug: debug}
+}
+
+type stdLogger struct {
+	logger *log.Logger
+	debug  bool
+}
+
+func (s stdLogger) print(level string, msg string, args []interface{}) {
+	var b strings.Builder
+	b.WriteString(level)
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", args[i])
+		}
+	}
+	s.logger.Print(b.String())
+}
+
+func (s stdLogger) Debug(msg string, args ...interface{}) {
+	if s.debug {
+		s.print("DEBUG", msg, args)
+	}
+}
+func (s stdLogger) Info(msg string, args ...interface{})  { s.print("INFO", msg, args) }
+func (s stdLogger) Warn(msg string, args ...interface{})  { s.print("WARN", msg, args) }
+func (s stdLogger) Error(msg string, args ...interface{}) { s.print("ERROR", msg, args) }
@@ -0,0 +1,84 @@
+package output
+
+import (
+	"bytes"
+	"sort"
+)
+
+//Tag is a dimension of a metric name,such as method=GET
+type Tag struct {
+	Key   string
+	Value string
+}
+
+//MetricName is a base name plus ordered tags,such as http.latency{method=GET,status=200}.
+//The tags keep the order they were given in,which is the order reporters without native tags render them.
+type MetricName struct {
+	Name string
+	Tags []Tag
+}
+
+//NewMetricName builds a MetricName from the base name and alternating tag keys and values,a trailing key without value is ignored
+func NewMetricName(name string, keyValues ...string) MetricName {
+	n := MetricName{Name: name}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		n = n.Tagged(keyValues[i], keyValues[i+1])
+	}
+	return n
+}
+
+//Tagged returns a copy of the name with the tag added,or replaced if the key is already present
+func (n MetricName) Tagged(key, value string) MetricName {
+	tags := make([]Tag, 0, len(n.Tags)+1)
+	replaced := false
+	for _, t := range n.Tags {
+		if t.Key == key {
+			t.Value = value
+			replaced = true
+		}
+		tags = append(tags, t)
+	}
+	if !replaced {
+		tags = append(tags, Tag{Key: key, Value: value})
+	}
+	return MetricName{Name: n.Name, Tags: tags}
+}
+
+//Tag returns the value of the tag and whether it is present
+func (n MetricName) Tag(key string) (string, bool) {
+	for _, t := range n.Tags {
+		if t.Key == key {
+			return t.Value, true
+		}
+	}
+	return "", false
+}
+
+//SortedTags returns the tags sorted by key
+func (n MetricName) SortedTags() []Tag {
+	tags := make([]Tag, len(n.Tags))
+	copy(tags, n.Tags)
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	return tags
+}
+
+//String is the key of the metric in the registry: the base name followed by the tags sorted by key,
+//so that the same tags given in another order name the same metric
+func (n MetricName) String() string {
+	if len(n.Tags) == 0 {
+		return n.Name
+	}
+	var buf bytes.Buffer
+	buf.WriteString(n.Name)
+	buf.WriteByte('{')
+	for i, t := range n.SortedTags() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(t.Key)
+		buf.WriteByte('=')
+		buf.WriteString(t.Value)
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
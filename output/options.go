@@ -0,0 +1,102 @@
+package output
+
+import "time"
+
+// Options holds the reporting settings shared by every reporter and by MarshalJson.
+type Options struct {
+	Percentiles  []float64     // Percentiles to report from timers and histograms,DefaultPercentiles if empty
+	DurationUnit time.Duration // Unit timer durations are converted to,time.Nanosecond if zero
+	RateUnit     time.Duration // Unit rates are expressed per,time.Second if zero
+	Filter       MetricFilter  // Only the matching metrics are reported,all of them if nil
+	Logger       Logger        // Receives the report failures,silent if nil
+	Timeout      time.Duration // Limit of connecting and writing to the server of a network reporter,DefaultTimeout if zero
+}
+
+//DefaultTimeout limits the reports to a server when the options have no Timeout,so that a server down can't block them
+const DefaultTimeout = 10 * time.Second
+
+//Log returns the Logger of the options,NopLogger if nil
+func (o Options) Log() Logger {
+	if o.Logger == nil {
+		return NopLogger
+	}
+	return o.Logger
+}
+
+//Timeouts returns the limit of connecting and writing to a server
+func (o Options) Timeouts() time.Duration {
+	if o.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return o.Timeout
+}
+
+//Durations returns the unit durations are reported in
+func (o Options) Durations() time.Duration {
+	if o.DurationUnit <= 0 {
+		return time.Nanosecond
+	}
+	return o.DurationUnit
+}
+
+//Rates returns the unit rates are reported per
+func (o Options) Rates() time.Duration {
+	if o.RateUnit <= 0 {
+		return time.Second
+	}
+	return o.RateUnit
+}
+
+//ConvertDuration converts nanoseconds into the duration unit
+func (o Options) ConvertDuration(nanoseconds float64) float64 {
+	return nanoseconds / float64(o.Durations())
+}
+
+//ConvertRate converts a per second rate into a rate per the rate unit
+func (o Options) ConvertRate(perSecond float64) float64 {
+	return perSecond * o.Rates().Seconds()
+}
+
+//DurationSuffix is the symbol of the duration unit,such as ms
+func (o Options) DurationSuffix() string {
+	return unitSymbol(o.Durations())
+}
+
+//RateSuffix is the symbol of the rate unit,such as /s
+func (o Options) RateSuffix() string {
+	return "/" + unitSymbol(o.Rates())
+}
+
+func unitSymbol(unit time.Duration) string {
+	switch unit {
+	case time.Nanosecond:
+		return "ns"
+	case time.Microsecond:
+		return "us"
+	case time.Millisecond:
+		return "ms"
+	case time.Second:
+		return "s"
+	case time.Minute:
+		return "min"
+	case time.Hour:
+		return "h"
+	}
+	return unit.String()
+}
+
+//ParseUnitSymbol returns the unit of a DurationSuffix,or of a RateSuffix without its leading '/'
+func ParseUnitSymbol(symbol string) (time.Duration, bool) {
+	for _, unit := range []time.Duration{time.Nanosecond, time.Microsecond, time.Millisecond, time.Second, time.Minute, time.Hour} {
+		if unitSymbol(unit) == symbol {
+			return unit, true
+		}
+	}
+	unit, err := time.ParseDuration(symbol)
+	return unit, err == nil && unit > 0
+}
+
+//MetricFilter selects the metrics to report,registry provides the usual implementations
+type MetricFilter interface {
+	Matches(name MetricName, metric interface{}) bool
+}
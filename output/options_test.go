@@ -0,0 +1,100 @@
+package output
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOptionsUnits(t *testing.T) {
+	tests := []struct {
+		name           string
+		options        Options
+		durations      time.Duration
+		rates          time.Duration
+		durationSuffix string
+		rateSuffix     string
+	}{
+		{"defaults", Options{}, time.Nanosecond, time.Second, "ns", "/s"},
+		{"negative units", Options{DurationUnit: -1, RateUnit: -1}, time.Nanosecond, time.Second, "ns", "/s"},
+		{"microseconds per minute", Options{DurationUnit: time.Microsecond, RateUnit: time.Minute}, time.Microsecond, time.Minute, "us", "/min"},
+		{"milliseconds per hour", Options{DurationUnit: time.Millisecond, RateUnit: time.Hour}, time.Millisecond, time.Hour, "ms", "/h"},
+		{"seconds", Options{DurationUnit: time.Second}, time.Second, time.Second, "s", "/s"},
+		{"unusual units", Options{DurationUnit: 10 * time.Millisecond, RateUnit: 90 * time.Second}, 10 * time.Millisecond, 90 * time.Second, "10ms", "/1m30s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.Durations(); got != tt.durations {
+				t.Errorf("Durations() = %v, want %v", got, tt.durations)
+			}
+			if got := tt.options.Rates(); got != tt.rates {
+				t.Errorf("Rates() = %v, want %v", got, tt.rates)
+			}
+			if got := tt.options.DurationSuffix(); got != tt.durationSuffix {
+				t.Errorf("DurationSuffix() = %q, want %q", got, tt.durationSuffix)
+			}
+			if got := tt.options.RateSuffix(); got != tt.rateSuffix {
+				t.Errorf("RateSuffix() = %q, want %q", got, tt.rateSuffix)
+			}
+		})
+	}
+}
+
+func TestOptionsConvert(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     Options
+		nanoseconds float64
+		duration    float64
+		perSecond   float64
+		rate        float64
+	}{
+		{"defaults", Options{}, 1500, 1500, 2, 2},
+		{"milliseconds per minute", Options{DurationUnit: time.Millisecond, RateUnit: time.Minute}, 1.5e6, 1.5, 2, 120},
+		{"seconds per hour", Options{DurationUnit: time.Second, RateUnit: time.Hour}, 3e9, 3, 0.5, 1800},
+		{"per millisecond", Options{RateUnit: time.Millisecond}, 0, 0, 1000, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.ConvertDuration(tt.nanoseconds); got != tt.duration {
+				t.Errorf("ConvertDuration(%v) = %v, want %v", tt.nanoseconds, got, tt.duration)
+			}
+			if got := tt.options.ConvertRate(tt.perSecond); got != tt.rate {
+				t.Errorf("ConvertRate(%v) = %v, want %v", tt.perSecond, got, tt.rate)
+			}
+		})
+	}
+}
+
+func TestParseUnitSymbol(t *testing.T) {
+	tests := []struct {
+		symbol string
+		unit   time.Duration
+		ok     bool
+	}{
+		{"ns", time.Nanosecond, true},
+		{"us", time.Microsecond, true},
+		{"ms", time.Millisecond, true},
+		{"s", time.Second, true},
+		{"min", time.Minute, true},
+		{"h", time.Hour, true},
+		{"10ms", 10 * time.Millisecond, true},
+		{"1m30s", 90 * time.Second, true},
+		{"", 0, false},
+		{"fortnight", 0, false},
+		{"-1s", 0, false},
+	}
+	for _, tt := range tests {
+		unit, ok := ParseUnitSymbol(tt.symbol)
+		if ok != tt.ok || (ok && unit != tt.unit) {
+			t.Errorf("ParseUnitSymbol(%q) = %v, %v, want %v, %v", tt.symbol, unit, ok, tt.unit, tt.ok)
+		}
+	}
+	for _, o := range []Options{{}, {DurationUnit: time.Millisecond, RateUnit: time.Minute}, {DurationUnit: 10 * time.Millisecond}} {
+		if unit, ok := ParseUnitSymbol(o.DurationSuffix()); !ok || unit != o.Durations() {
+			t.Errorf("ParseUnitSymbol(%q) = %v, %v, want %v", o.DurationSuffix(), unit, ok, o.Durations())
+		}
+		if unit, ok := ParseUnitSymbol(o.RateSuffix()[1:]); !ok || unit != o.Rates() {
+			t.Errorf("ParseUnitSymbol(%q) = %v, %v, want %v", o.RateSuffix()[1:], unit, ok, o.Rates())
+		}
+	}
+}
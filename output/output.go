@@ -1,5 +1,58 @@
 package output
 
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+//DefaultPercentiles are reported when neither the metric nor the reporter specifies its own ones
+var DefaultPercentiles = []float64{0.5, 0.75, 0.95, 0.98, 0.99, 0.999}
+
+//PercentileKey names a percentile for reporting,0.5 is p50 and 0.999 is p999.The decimals follow the first two digits,
+//below the 10th percentile they follow an underscore so that 0.055 is p5_5 and never collides with p55,0.55
+func PercentileKey(percentile float64) string {
+	hundredth := math.Round(percentile*100*1e4) / 1e4
+	digits := strconv.FormatFloat(hundredth, 'f', -1, 64)
+	if point := strings.IndexByte(digits, '.'); point == 1 {
+		digits = digits[:1] + "_" + digits[2:]
+	} else if point > 1 {
+		digits = digits[:point] + digits[point+1:]
+	}
+	return "p" + digits
+}
+
+//ParsePercentileKey is the reverse of PercentileKey,p999 is 0.999,p5_5 is 0.055 and p0_5 is 0.005
+func ParsePercentileKey(key string) (float64, bool) {
+	digits := strings.TrimPrefix(key, "p")
+	if digits == key || digits == "" || strings.TrimLeft(digits, "0123456789_") != "" {
+		return 0, false
+	}
+	//split the hundredths and their decimals,the point is then moved in the text to parse the exact quantile
+	var hundredths, decimals string
+	switch point := strings.IndexByte(digits, '_'); {
+	case point >= 0:
+		if point != 1 || point == len(digits)-1 || strings.Count(digits, "_") > 1 {
+			return 0, false
+		}
+		hundredths, decimals = "0"+digits[:1], digits[2:]
+	case digits == "100":
+		return 1, true
+	case digits[0] == '0' && len(digits) > 1:
+		//written before the underscore was introduced,p05 is 0.005
+		hundredths, decimals = "00", digits[1:]
+	case len(digits) == 1:
+		hundredths = "0" + digits
+	default:
+		hundredths, decimals = digits[:2], digits[2:]
+	}
+	quantile, err := strconv.ParseFloat("0."+hundredths+decimals, 64)
+	if err != nil {
+		return 0, false
+	}
+	return quantile, true
+}
+
 //Counting
 type Counting interface {
 	Count() int64
@@ -27,9 +80,17 @@ type GaugedFloat64 interface {
 
 type Histogram interface {
 	Count() int64
+	Sum() int64
+}
+
+//Percentiled is implemented by both Snapshot and SnapshotFloat64
+type Percentiled interface {
+	Value(percentile float64) float64
 }
 
 type Snapshot interface {
+	Count() int64 //number of recorded values,not only the sampled ones
+	Sum() int64   //sum of all the recorded values
 	Value(percentile float64) float64
 	Values() []float64
 	Size() int64
@@ -45,3 +106,28 @@ type Snapshot interface {
 	Get999thPercentile() float64
 	Percentiles() []float64
 }
+
+//SnapshotFloat64 is the snapshot of a histogram of float64 values
+type SnapshotFloat64 interface {
+	Count() int64 //number of recorded values,not only the sampled ones
+	Sum() float64 //sum of all the recorded values
+	Value(percentile float64) float64
+	Values() []float64
+	Size() int64
+	Max() float64
+	StdDev() float64
+	Mean() float64
+	Min() float64
+	Percentiles() []float64
+}
+
+//Bucket is a cumulative bucket counting the values less than or equal to UpperBound
+type Bucket struct {
+	UpperBound float64
+	Count      int64
+}
+
+//Bucketed is implemented by snapshots of histograms with fixed buckets,the last bucket is always +Inf
+type Bucketed interface {
+	Buckets() []Bucket
+}
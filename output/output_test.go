@@ -0,0 +1,73 @@
+package output
+
+import "testing"
+
+func TestPercentileKey(t *testing.T) {
+	tests := []struct {
+		percentile float64
+		key        string
+	}{
+		{0.5, "p50"},
+		{0.75, "p75"},
+		{0.95, "p95"},
+		{0.99, "p99"},
+		{0.999, "p999"},
+		{0.9999, "p9999"},
+		{0.55, "p55"},
+		{0.555, "p555"},
+		{0.1, "p10"},
+		{0.05, "p5"},
+		{0.01, "p1"},
+		{0.055, "p5_5"},
+		{0.075, "p7_5"},
+		{0.005, "p0_5"},
+		{0.0001, "p0_01"},
+		{1, "p100"},
+	}
+	for _, tt := range tests {
+		key := PercentileKey(tt.percentile)
+		if key != tt.key {
+			t.Errorf("PercentileKey(%v) = %q, want %q", tt.percentile, key, tt.key)
+		}
+		if p, ok := ParsePercentileKey(key); !ok || p != tt.percentile {
+			t.Errorf("ParsePercentileKey(%q) = %v, %v, want %v", key, p, ok, tt.percentile)
+		}
+	}
+}
+
+func TestPercentileKeysDontCollide(t *testing.T) {
+	seen := make(map[string]float64)
+	for i := 1; i <= 1000; i++ {
+		p := float64(i) / 1000
+		key := PercentileKey(p)
+		if other, ok := seen[key]; ok {
+			t.Fatalf("PercentileKey(%v) = PercentileKey(%v) = %q", p, other, key)
+		}
+		seen[key] = p
+	}
+}
+
+func TestParsePercentileKey(t *testing.T) {
+	tests := []struct {
+		key        string
+		percentile float64
+		ok         bool
+	}{
+		{"p05", 0.005, true}, //written before the underscore was introduced
+		{"p0_5", 0.005, true},
+		{"p", 0, false},
+		{"50", 0, false},
+		{"q50", 0, false},
+		{"p5x", 0, false},
+		{"p_5", 0, false},
+		{"p55_5", 0, false},
+		{"p5_", 0, false},
+		{"p5_5_5", 0, false},
+	}
+	for _, tt := range tests {
+		p, ok := ParsePercentileKey(tt.key)
+		if ok != tt.ok || p != tt.percentile {
+			t.Errorf("ParsePercentileKey(%q) = %v, %v, want %v, %v", tt.key, p, ok, tt.percentile, tt.ok)
+		}
+	}
+}
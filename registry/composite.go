@@ -0,0 +1,366 @@
+package registry
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+/***
+CompositeRegistry is a view of child registries each mounted under a namespace,such as the registries of the libraries
+of a service,so that one reporter covers all of them without copying metrics.
+The metric name of a registry mounted under db is db.name in the composite,or just name with an empty namespace.
+When several registries hold the same full name,the first mounted one wins,see Collisions.
+The universal prefixes of the children aren't applied,mount them under a namespace instead.
+*/
+type CompositeRegistry struct {
+	mutex     sync.RWMutex
+	mounts    []mount //copied on write,so that the readers can iterate it unlocked
+	listeners []RegistryListener
+}
+
+type mount struct {
+	namespace string
+	registry  Registry
+}
+
+func NewCompositeRegistry() *CompositeRegistry {
+	return &CompositeRegistry{}
+}
+
+func joinNamespace(namespace string, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+//the name in the composite
+func (m mount) fullName(name string) string {
+	return joinNamespace(m.namespace, name)
+}
+
+//the name in the child and whether the name of the composite belongs to the namespace
+func (m mount) childName(name string) (string, bool) {
+	if m.namespace == "" {
+		return name, true
+	}
+	return trimScope(m.namespace, name)
+}
+
+func (m mount) childMetricName(name MetricName) (MetricName, bool) {
+	n, ok := m.childName(name.Name)
+	return MetricName{Name: n, Tags: name.Tags}, ok
+}
+
+// Mount adds the registry under the namespace,empty for none.
+// Returns a *CollisionError if a name of the registry is already held by the composite,the registry isn't mounted then.
+func (c *CompositeRegistry) Mount(namespace string, r Registry) error {
+	c.mutex.Lock()
+	m := mount{namespace: namespace, registry: r}
+	held := make(map[string]bool)
+	eachMounted(c.mounts, func(name string, _ interface{}, _ bool) {
+		held[name] = true
+	})
+	var collisions []string
+	r.Each(func(name string, _ interface{}) {
+		if full := m.fullName(name); held[full] {
+			collisions = append(collisions, full)
+		}
+	})
+	if len(collisions) > 0 {
+		c.mutex.Unlock()
+		sort.Strings(collisions)
+		return &CollisionError{Namespace: namespace, Names: collisions}
+	}
+	c.mounts = append(c.mounts[:len(c.mounts):len(c.mounts)], m)
+	listeners := c.listeners
+	c.mutex.Unlock()
+	for _, l := range listeners {
+		r.AddListener(mountedListener{namespace: namespace, listener: l})
+	}
+	return nil
+}
+
+// Unmount removes the registries mounted under the namespace,they keep their metrics.
+func (c *CompositeRegistry) Unmount(namespace string) {
+	c.mutex.Lock()
+	var kept, removed []mount
+	for _, m := range c.mounts {
+		if m.namespace == namespace {
+			removed = append(removed, m)
+		} else {
+			kept = append(kept, m)
+		}
+	}
+	c.mounts = kept
+	listeners := c.listeners
+	c.mutex.Unlock()
+	for _, m := range removed {
+		for _, l := range listeners {
+			m.registry.RemoveListener(mountedListener{namespace: namespace, listener: l})
+		}
+	}
+}
+
+// Collisions returns the full names held by more than one mounted registry,sorted.
+// Only the metric of the first mounted registry is visible under such a name.
+// Mount and the registrations through the composite refuse the names already held,but a metric registered
+// directly into a child after it's mounted isn't checked,Collisions is how to find such names.
+func (c *CompositeRegistry) Collisions() []string {
+	var collisions []string
+	eachMounted(c.mounted(), func(name string, _ interface{}, shadowed bool) {
+		if shadowed {
+			collisions = append(collisions, name)
+		}
+	})
+	sort.Strings(collisions)
+	return collisions
+}
+
+func (c *CompositeRegistry) mounted() []mount {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.mounts
+}
+
+//call f for every metric of the mounts with its full name,shadowed is true for the ones hidden by an earlier mount
+func eachMounted(mounts []mount, f func(name string, i interface{}, shadowed bool)) {
+	seen := make(map[string]bool)
+	for _, m := range mounts {
+		m.registry.Each(func(name string, i interface{}) {
+			full := m.fullName(name)
+			f(full, i, seen[full])
+			seen[full] = true
+		})
+	}
+}
+
+//locate returns the mount holding the name and the name in it
+func (c *CompositeRegistry) locate(name string) (mount, string, bool) {
+	for _, m := range c.mounted() {
+		if n, ok := m.childName(name); ok && m.registry.Get(n) != nil {
+			return m, n, true
+		}
+	}
+	return mount{}, "", false
+}
+
+func (c *CompositeRegistry) locateTagged(name MetricName) (mount, MetricName, bool) {
+	for _, m := range c.mounted() {
+		if n, ok := m.childMetricName(name); ok && m.registry.GetTagged(n) != nil {
+			return m, n, true
+		}
+	}
+	return mount{}, MetricName{}, false
+}
+
+//target returns the mount new metrics of the name are registered in: the one with the longest namespace holding the name,
+//the first mounted one among equals
+func (c *CompositeRegistry) target(name string) (mount, string, bool) {
+	var target mount
+	var child string
+	found := false
+	for _, m := range c.mounted() {
+		if n, ok := m.childName(name); ok && (!found || len(m.namespace) > len(target.namespace)) {
+			target, child, found = m, n, true
+		}
+	}
+	return target, child, found
+}
+
+func (c *CompositeRegistry) targetTagged(name MetricName) (mount, MetricName, bool) {
+	m, n, ok := c.target(name.Name)
+	return m, MetricName{Name: n, Tags: name.Tags}, ok
+}
+
+// Call the given function for each metric of the mounted registries,with the full name.
+func (c *CompositeRegistry) Each(f func(string, interface{})) {
+	eachMounted(c.mounted(), func(name string, i interface{}, shadowed bool) {
+		if !shadowed {
+			f(name, i)
+		}
+	})
+}
+
+func (c *CompositeRegistry) Get(name string) interface{} {
+	if m, n, ok := c.locate(name); ok {
+		return m.registry.Get(n)
+	}
+	return nil
+}
+
+func (c *CompositeRegistry) GetOrRegister(name string, i interface{}, opts ...MetricOption) interface{} {
+	if val := c.Get(name); val != nil {
+		return val
+	}
+	m, n, ok := c.target(name)
+	if !ok {
+		return unregistered(i)
+	}
+	return m.registry.GetOrRegister(n, i, opts...)
+}
+
+// Register the given metric in the mount of the name,see target.
+// Returns a *DuplicateMetricError if any mounted registry holds the name,and a *NoMountError if no registry is mounted for it.
+func (c *CompositeRegistry) Register(name string, i interface{}, opts ...MetricOption) error {
+	if c.Get(name) != nil {
+		return &DuplicateMetricError{Name: name}
+	}
+	m, n, ok := c.target(name)
+	if !ok {
+		return &NoMountError{Name: name}
+	}
+	return m.registry.Register(n, i, opts...)
+}
+
+func (c *CompositeRegistry) Options(name string) MetricOptions {
+	if m, n, ok := c.locate(name); ok {
+		return m.registry.Options(n)
+	}
+	return MetricOptions{}
+}
+
+func (c *CompositeRegistry) Describe(name string) (MetricDescription, bool) {
+	m, n, ok := c.locate(name)
+	if !ok {
+		return MetricDescription{}, false
+	}
+	d, ok := m.registry.Describe(n)
+	if ok {
+		d.Name.Name = m.fullName(d.Name.Name)
+	}
+	return d, ok
+}
+
+func (c *CompositeRegistry) EachTagged(f func(MetricName, interface{})) {
+	seen := make(map[string]bool)
+	for _, m := range c.mounted() {
+		m.registry.EachTagged(func(name MetricName, i interface{}) {
+			full := MetricName{Name: m.fullName(name.Name), Tags: name.Tags}
+			if key := full.String(); !seen[key] {
+				seen[key] = true
+				f(full, i)
+			}
+		})
+	}
+}
+
+func (c *CompositeRegistry) GetTagged(name MetricName) interface{} {
+	if m, n, ok := c.locateTagged(name); ok {
+		return m.registry.GetTagged(n)
+	}
+	return nil
+}
+
+func (c *CompositeRegistry) GetOrRegisterTagged(name MetricName, i interface{}, opts ...MetricOption) interface{} {
+	if val := c.GetTagged(name); val != nil {
+		return val
+	}
+	m, n, ok := c.targetTagged(name)
+	if !ok {
+		return unregistered(i)
+	}
+	return m.registry.GetOrRegisterTagged(n, i, opts...)
+}
+
+func (c *CompositeRegistry) RegisterTagged(name MetricName, i interface{}, opts ...MetricOption) error {
+	if c.GetTagged(name) != nil {
+		return &DuplicateMetricError{Name: name.String()}
+	}
+	m, n, ok := c.targetTagged(name)
+	if !ok {
+		return &NoMountError{Name: name.String()}
+	}
+	return m.registry.RegisterTagged(n, i, opts...)
+}
+
+func (c *CompositeRegistry) UnregisterTagged(name MetricName) {
+	if m, n, ok := c.locateTagged(name); ok {
+		m.registry.UnregisterTagged(n)
+	}
+}
+
+func (c *CompositeRegistry) Unregister(name string) {
+	if m, n, ok := c.locate(name); ok {
+		m.registry.Unregister(n)
+	}
+}
+
+// Unregister all the metrics of every mounted registry.
+func (c *CompositeRegistry) UnregisterAll() {
+	for _, m := range c.mounted() {
+		m.registry.UnregisterAll()
+	}
+}
+
+// Prefix is always empty,the namespaces are part of the names.
+func (c *CompositeRegistry) Prefix() string {
+	return ""
+}
+
+func (c *CompositeRegistry) Scope(name string) Registry {
+	return &ScopedRegistry{root: c, scope: name}
+}
+
+func (c *CompositeRegistry) UnregisterScope(name string) {
+	c.Scope(name).UnregisterAll()
+}
+
+// AddListener adds the listener to every mounted registry and to the ones mounted later,with the full names.
+func (c *CompositeRegistry) AddListener(l RegistryListener) {
+	c.mutex.Lock()
+	c.listeners = append(c.listeners, l)
+	mounts := c.mounts
+	c.mutex.Unlock()
+	for _, m := range mounts {
+		m.registry.AddListener(mountedListener{namespace: m.namespace, listener: l})
+	}
+}
+
+func (c *CompositeRegistry) RemoveListener(l RegistryListener) {
+	c.mutex.Lock()
+	for i, listener := range c.listeners {
+		if listener == l {
+			c.listeners = append(c.listeners[:i:i], c.listeners[i+1:]...)
+			break
+		}
+	}
+	mounts := c.mounts
+	c.mutex.Unlock()
+	for _, m := range mounts {
+		m.registry.RemoveListener(mountedListener{namespace: m.namespace, listener: l})
+	}
+}
+
+func (c *CompositeRegistry) MarshalJson() ([]byte, error) {
+	return c.MarshalJsonWithOptions(output.Options{})
+}
+
+func (c *CompositeRegistry) MarshalJsonWithOptions(o output.Options) ([]byte, error) {
+	return marshalJson(c, o)
+}
+
+// Names returns the full names of the metrics of the mounted registries,sorted.
+func (c *CompositeRegistry) Names() []string {
+	return sortedNames(c)
+}
+
+// Find returns the metrics whose full base name matches the shell pattern,such as db.*.
+func (c *CompositeRegistry) Find(pattern string) map[string]interface{} {
+	return find(c, pattern)
+}
+
+func (c *CompositeRegistry) EachMatching(filter MetricFilter, f func(MetricName, interface{})) {
+	EachFiltered(c, filter, f)
+}
+
+//the metric GetOrRegister returns without registering it,built if given as a constructor
+func unregistered(i interface{}) interface{} {
+	if v := reflect.ValueOf(i); v.Kind() == reflect.Func {
+		return v.Call(nil)[0].Interface()
+	}
+	return i
+}
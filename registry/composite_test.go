@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+//a registry holding a counter under each name
+func registryOf(names ...string) Registry {
+	r := NewRegistry()
+	for _, name := range names {
+		r.Register(name, metrics.NewCounter())
+	}
+	return r
+}
+
+func TestCompositeMount(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		child     Registry
+		wantErr   []string //collisions of the mount,nil if it succeeds
+		wantNames []string
+	}{
+		{"namespace", "api", registryOf("requests"), nil, []string{"api.requests", "db.queries", "db.size"}},
+		{"no namespace", "", registryOf("uptime"), nil, []string{"db.queries", "db.size", "uptime"}},
+		{"collision", "db", registryOf("size", "queries", "conns"), []string{"db.queries", "db.size"}, []string{"db.queries", "db.size"}},
+		{"collision without namespace", "", registryOf("db.size"), []string{"db.size"}, []string{"db.queries", "db.size"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCompositeRegistry()
+			if err := c.Mount("db", registryOf("queries", "size")); err != nil {
+				t.Fatal(err)
+			}
+			err := c.Mount(tt.namespace, tt.child)
+			if tt.wantErr == nil && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != nil {
+				collision, ok := err.(*CollisionError)
+				if !ok || !reflect.DeepEqual(collision.Names, tt.wantErr) {
+					t.Errorf("Mount() error = %v,want a *CollisionError on %v", err, tt.wantErr)
+				}
+			}
+			if names := c.Names(); !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("Names() = %v,want %v", names, tt.wantNames)
+			}
+			if collisions := c.Collisions(); collisions != nil {
+				t.Errorf("Collisions() = %v,want none after a refused mount", collisions)
+			}
+		})
+	}
+}
+
+//the names registered directly into a child after mounting aren't refused,Collisions reports them
+func TestCompositeCollisionsAfterMount(t *testing.T) {
+	c := NewCompositeRegistry()
+	first, second := registryOf("a"), registryOf("b")
+	if err := c.Mount("", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Mount("", second); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Register("b", metrics.NewCounter()); err == nil {
+		t.Error("Register() through the composite accepted a name held by a mount")
+	}
+	shadowed := metrics.NewCounter()
+	if err := second.Register("a", shadowed); err != nil {
+		t.Fatal(err)
+	}
+	if collisions := c.Collisions(); !reflect.DeepEqual(collisions, []string{"a"}) {
+		t.Errorf("Collisions() = %v,want [a]", collisions)
+	}
+	if c.Get("a") == shadowed {
+		t.Error("Get() returned the metric of the last mount,want the one of the first mount")
+	}
+	var seen int
+	c.Each(func(name string, _ interface{}) {
+		if name == "a" {
+			seen++
+		}
+	})
+	if seen != 1 {
+		t.Errorf("Each() visited a %d times,want once", seen)
+	}
+}
@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+//DuplicateMetricError is returned when registering a name that is already registered
+type DuplicateMetricError struct {
+	Name string
+}
+
+func (e *DuplicateMetricError) Error() string {
+	return fmt.Sprintf("duplicate metric: %s is already registered", e.Name)
+}
+
+//TypeMismatchError is returned when the metric registered under a name isn't of the requested type
+type TypeMismatchError struct {
+	Name     string
+	Existing MetricKind //kind of the registered metric
+	Wanted   string     //requested type
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("type mismatch: %s is registered as a %s,not a %s", e.Name, e.Existing, e.Wanted)
+}
+
+//UnsupportedMetricError is returned when registering a value which isn't a supported metric
+type UnsupportedMetricError struct {
+	Name  string
+	Value interface{}
+}
+
+func (e *UnsupportedMetricError) Error() string {
+	return fmt.Sprintf("unsupported metric: %s can't be registered as a %T", e.Name, e.Value)
+}
+
+//InvalidNameError is returned when registering a name rejected by the NamePolicy of the registry
+type InvalidNameError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InvalidNameError) Error() string {
+	return fmt.Sprintf("invalid metric name: %s can't be registered,%s", e.Name, e.Reason)
+}
+
+//CollisionError is returned when mounting a registry holding names already held by the other registries of a CompositeRegistry
+type CollisionError struct {
+	Namespace string
+	Names     []string //full names held twice
+}
+
+func (e *CollisionError) Error() string {
+	return fmt.Sprintf("name collision: mounting under %q would hold %s twice", e.Namespace, strings.Join(e.Names, ","))
+}
+
+//NoMountError is returned when registering a name in a CompositeRegistry which has no registry mounted for it
+type NoMountError struct {
+	Name string
+}
+
+func (e *NoMountError) Error() string {
+	return fmt.Sprintf("no mount: %s can't be registered,no registry is mounted under its namespace", e.Name)
+}
+
+//ReadOnlyError is returned when registering a name in a read-only registry,such as the one of ParseSnapshotJSON
+type ReadOnlyError struct {
+	Name string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("read-only registry: %s can't be registered", e.Name)
+}
@@ -0,0 +1,224 @@
+package registry
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+//ExpiryPolicy unregisters the metrics not updated for IdleTimeout.
+//Only the metrics implementing mechanism.Expirable can expire,a metric never updated is idle since the sweeper first saw it.
+//The sweeper makes the metrics it sees timestamp their updates,see mechanism.UpdateTracker,
+//from the sweep first seeing them until they're unregistered or the sweeper stops
+type ExpiryPolicy struct {
+	IdleTimeout time.Duration
+	Interval    time.Duration //time between two sweeps,IdleTimeout/2 if not set
+	Filter      MetricFilter  //only the matching metrics can expire,all of them if nil
+	//Clock gives the time of the background sweeps,metrics.SystemClock if nil.
+	//Use the clock the metrics are created with so that their updates and the sweeps agree
+	Clock mechanism.Clock
+	//OnEvict receives a registry holding the metrics evicted by a sweep,
+	//pass it to a reporter for a final flush of their values
+	OnEvict func(evicted Registry)
+}
+
+//ExpirySweeper runs an ExpiryPolicy against a registry in the background
+type ExpirySweeper struct {
+	registry Registry
+	policy   ExpiryPolicy
+	mutex    sync.Mutex
+	seen     map[string]seenMetric //metrics seen by the sweeper,whose updates are tracked
+	stopped  bool
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+	started  bool
+}
+
+//seenMetric is a metric seen by a sweeper and the first time the sweeper saw it
+type seenMetric struct {
+	metric interface{}
+	at     time.Time
+}
+
+//NewExpirySweeper creates a sweeper without starting it,call Sweep to run it by hand
+func NewExpirySweeper(r Registry, policy ExpiryPolicy) *ExpirySweeper {
+	if policy.Interval <= 0 {
+		policy.Interval = policy.IdleTimeout / 2
+	}
+	if policy.Interval <= 0 {
+		policy.Interval = time.Second
+	}
+	if policy.Clock == nil {
+		policy.Clock = metrics.SystemClock
+	}
+	return &ExpirySweeper{
+		registry: r,
+		policy:   policy,
+		seen:     make(map[string]seenMetric),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+//StartExpiry starts sweeping the registry every policy.Interval,call Stop on the returned sweeper to end it.
+//The first sweep runs before it returns,so that the updates of the metrics already registered are tracked from now on
+func StartExpiry(r Registry, policy ExpiryPolicy) *ExpirySweeper {
+	s := NewExpirySweeper(r, policy)
+	s.Sweep(s.policy.Clock.Now())
+	s.started = true
+	go s.run()
+	return s
+}
+
+func (s *ExpirySweeper) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.policy.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Sweep(s.policy.Clock.Now())
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+//Stop ends the background sweeps,waits for the running one to finish and stops tracking the updates of the metrics,
+//later sweeps do nothing
+func (s *ExpirySweeper) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	if s.started {
+		<-s.done
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stopped = true
+	for key, seen := range s.seen {
+		trackUpdates(seen.metric, false)
+		delete(s.seen, key)
+	}
+}
+
+//Sweep unregisters the metrics idle at now and returns their names
+func (s *ExpirySweeper) Sweep(now time.Time) []MetricName {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stopped {
+		return nil
+	}
+	var names []MetricName
+	var metrics []interface{}
+	present := make(map[string]bool)
+	s.registry.EachTagged(func(name MetricName, i interface{}) {
+		expirable, ok := i.(mechanism.Expirable)
+		if !ok {
+			return
+		}
+		key := name.String()
+		present[key] = true
+		seen, ok := s.seen[key]
+		if !ok || !sameMetric(seen.metric, i) {
+			//first seen,or replaced since the last sweep
+			if ok {
+				trackUpdates(seen.metric, false)
+			}
+			trackUpdates(i, true)
+			seen = seenMetric{metric: i, at: now}
+			s.seen[key] = seen
+		}
+		if !s.idle(now, seen.at, expirable) {
+			return
+		}
+		if s.policy.Filter != nil && !s.policy.Filter.Matches(name, i) {
+			return
+		}
+		names = append(names, name)
+		metrics = append(metrics, i)
+	})
+	for key, seen := range s.seen {
+		if !present[key] {
+			trackUpdates(seen.metric, false)
+			delete(s.seen, key)
+		}
+	}
+	var evicted Registry
+	var removed []MetricName
+	for i, name := range names {
+		key := name.String()
+		options := s.registry.Options(key)
+		expirable, seenAt := metrics[i].(mechanism.Expirable), s.seen[key].at
+		//updated or replaced since it was found idle,keep it
+		if !unregisterIf(s.registry, name, metrics[i], func() bool { return s.idle(now, seenAt, expirable) }) {
+			continue
+		}
+		trackUpdates(metrics[i], false)
+		delete(s.seen, key)
+		removed = append(removed, name)
+		if s.policy.OnEvict == nil {
+			continue
+		}
+		if evicted == nil {
+			evicted = NewPrefixRegistry(s.registry.Prefix())
+		}
+		evicted.RegisterTagged(name, metrics[i], func(o *MetricOptions) { *o = options })
+	}
+	if evicted != nil {
+		s.policy.OnEvict(evicted)
+	}
+	return removed
+}
+
+//idle tells whether the metric first seen at seenAt isn't updated since IdleTimeout before now
+func (s *ExpirySweeper) idle(now, seenAt time.Time, expirable mechanism.Expirable) bool {
+	last := seenAt
+	if updated := expirable.LastUpdate(); updated.After(last) {
+		last = updated
+	}
+	return now.Sub(last) >= s.policy.IdleTimeout
+}
+
+//trackUpdates starts or stops timestamping the updates of the metric,if it can
+func trackUpdates(metric interface{}, track bool) {
+	if t, ok := metric.(mechanism.UpdateTracker); ok {
+		t.TrackUpdates(track)
+	}
+}
+
+//sameMetric tells whether a and b are the same instance,metrics of uncomparable types are never the same
+func sameMetric(a, b interface{}) bool {
+	t := reflect.TypeOf(a)
+	return t != nil && t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
+
+//unregisterIf unregisters the metric of the name only if it's still the given instance and still accepted by check,
+//which runs just before the removal.It returns whether the metric was unregistered
+func unregisterIf(r Registry, name MetricName, metric interface{}, check func() bool) bool {
+	switch reg := r.(type) {
+	case *StandardRegistry:
+		if reg.policy() == NameNormalize {
+			name, _ = applyNamePolicy(NameNormalize, name)
+		}
+		return reg.remove(name.String(), func(e *metricEntry) bool { return sameMetric(e.metric, metric) && check() })
+	case *ScopedRegistry:
+		return unregisterIf(reg.root, reg.fullMetricName(name), metric, check)
+	case *CompositeRegistry:
+		if m, n, ok := reg.locateTagged(name); ok {
+			return unregisterIf(m.registry, n, metric, check)
+		}
+		return false
+	case *readOnlyRegistry:
+		return false
+	}
+	if !sameMetric(r.GetTagged(name), metric) || !check() {
+		return false
+	}
+	r.UnregisterTagged(name)
+	return true
+}
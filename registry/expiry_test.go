@@ -0,0 +1,157 @@
+package registry
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+//a clock moved by hand
+type manualClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Add(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestExpiryKeepsPrefixOfEvicted(t *testing.T) {
+	root := NewPrefixRegistry("app")
+	tests := []struct {
+		name     string
+		registry Registry
+		prefix   string
+	}{
+		{"root", root, "app"},
+		{"scope", root.Scope("db"), "app.db"},
+		{"nested scope", root.Scope("db").Scope("pool"), "app.db.pool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.registry.Register("idle", metrics.NewCounter())
+			var evicted Registry
+			s := NewExpirySweeper(tt.registry, ExpiryPolicy{IdleTimeout: time.Minute, OnEvict: func(r Registry) { evicted = r }})
+			defer s.Stop()
+			now := time.Now()
+			s.Sweep(now)
+			if names := s.Sweep(now.Add(time.Minute)); len(names) != 1 || names[0].Name != "idle" {
+				t.Fatalf("Sweep() = %v, want idle", names)
+			}
+			if evicted == nil {
+				t.Fatal("OnEvict wasn't called")
+			}
+			if prefix := evicted.Prefix(); prefix != tt.prefix {
+				t.Errorf("evicted.Prefix() = %q, want %q", prefix, tt.prefix)
+			}
+			if evicted.Get("idle") == nil {
+				t.Error("evicted registry doesn't hold idle")
+			}
+		})
+	}
+}
+
+func TestExpiryUsesClockOfMetrics(t *testing.T) {
+	clock := &manualClock{now: time.Unix(1000, 0)}
+	r := NewRegistry()
+	m := metrics.NewCustomMeter(clock, time.Minute, 5*time.Minute, 15*time.Minute)
+	r.Register("m", m)
+	s := NewExpirySweeper(r, ExpiryPolicy{IdleTimeout: time.Minute, Clock: clock})
+	defer s.Stop()
+	s.Sweep(clock.Now())
+	clock.Add(50 * time.Second)
+	m.Mark()
+	clock.Add(50 * time.Second)
+	if names := s.Sweep(clock.Now()); len(names) != 0 {
+		t.Fatalf("Sweep() = %v, want nothing 50s after the update", names)
+	}
+	clock.Add(10 * time.Second)
+	if names := s.Sweep(clock.Now()); len(names) != 1 {
+		t.Fatalf("Sweep() = %v, want m a minute after the update", names)
+	}
+}
+
+func TestUpdatesTrackedPerRegistry(t *testing.T) {
+	swept, other, unstarted := NewRegistry(), NewRegistry(), NewRegistry()
+	tests := []struct {
+		name     string
+		registry Registry
+		tracked  bool
+	}{
+		{"swept", swept, true},
+		{"other registry", other, false},
+		{"sweeper never started", unstarted, false},
+	}
+	for _, tt := range tests {
+		tt.registry.Register("c", metrics.NewCounter())
+	}
+	s := StartExpiry(swept, ExpiryPolicy{IdleTimeout: time.Hour})
+	NewExpirySweeper(unstarted, ExpiryPolicy{IdleTimeout: time.Hour})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.registry.Get("c").(mechanism.Counter)
+			c.Inc(1)
+			if last := c.(mechanism.Expirable).LastUpdate(); last.IsZero() == tt.tracked {
+				t.Errorf("LastUpdate() = %v,want tracked %v", last, tt.tracked)
+			}
+		})
+	}
+	s.Stop()
+	s.Stop()
+	c := swept.Get("c").(mechanism.Counter)
+	before := c.(mechanism.Expirable).LastUpdate()
+	c.Inc(1)
+	if last := c.(mechanism.Expirable).LastUpdate(); !last.Equal(before) {
+		t.Fatalf("LastUpdate() = %v after Stop, want %v", last, before)
+	}
+}
+
+func TestExpiryKeepsMetricChangedAfterCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(r Registry, c mechanism.Counter)
+	}{
+		{"updated", func(r Registry, c mechanism.Counter) { c.Inc(1) }},
+		{"replaced", func(r Registry, c mechanism.Counter) {
+			r.Unregister("c")
+			r.Register("c", metrics.NewCounter())
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			c := metrics.NewCounter()
+			r.Register("c", c)
+			var checked interface{}
+			//the filter runs once the metric is found idle,before its removal
+			filter := MetricFilterFunc(func(name MetricName, i interface{}) bool {
+				if checked == nil {
+					checked = i
+					tt.change(r, c)
+				}
+				return true
+			})
+			s := NewExpirySweeper(r, ExpiryPolicy{IdleTimeout: time.Minute, Filter: filter})
+			defer s.Stop()
+			now := time.Now()
+			s.Sweep(now)
+			if names := s.Sweep(now.Add(time.Minute)); len(names) != 0 {
+				t.Fatalf("Sweep() = %v, want nothing", names)
+			}
+			if r.Get("c") == nil {
+				t.Fatal("c was unregistered")
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package registry
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//MetricFilter selects the metrics to report,see output.MetricFilter
+type MetricFilter = output.MetricFilter
+
+//MetricFilterFunc adapts a function to a MetricFilter
+type MetricFilterFunc func(name MetricName, metric interface{}) bool
+
+func (f MetricFilterFunc) Matches(name MetricName, metric interface{}) bool {
+	return f(name, metric)
+}
+
+//MetricKind is the type of a registered metric
+type MetricKind int
+
+const (
+	KindUnknown MetricKind = iota
+	KindCounter
+	KindGauge
+	KindGauge64
+	KindHistogram
+	KindHistogramFloat64
+	KindMeter
+	KindTimer
+	KindHealthcheck
+)
+
+//KindOf returns the kind of the metric,KindUnknown for unsupported values
+func KindOf(i interface{}) MetricKind {
+	switch i.(type) {
+	case mechanism.Counter:
+		return KindCounter
+	case mechanism.Gauge:
+		return KindGauge
+	case mechanism.Gauge64:
+		return KindGauge64
+	case mechanism.Histogram:
+		return KindHistogram
+	case mechanism.HistogramFloat64:
+		return KindHistogramFloat64
+	case mechanism.Meter:
+		return KindMeter
+	case mechanism.Timer:
+		return KindTimer
+	case mechanism.Healthcheck:
+		return KindHealthcheck
+	}
+	return KindUnknown
+}
+
+func (k MetricKind) String() string {
+	switch k {
+	case KindCounter:
+		return "counter"
+	case KindGauge:
+		return "gauge"
+	case KindGauge64:
+		return "gauge64"
+	case KindHistogram:
+		return "histogram"
+	case KindHistogramFloat64:
+		return "histogram64"
+	case KindMeter:
+		return "meter"
+	case KindTimer:
+		return "timer"
+	case KindHealthcheck:
+		return "healthcheck"
+	}
+	return "unknown"
+}
+
+//NamePrefixFilter matches the metrics whose base name starts with the prefix
+func NamePrefixFilter(prefix string) MetricFilter {
+	return MetricFilterFunc(func(name MetricName, metric interface{}) bool {
+		return strings.HasPrefix(name.Name, prefix)
+	})
+}
+
+//GlobFilter matches the base names against a shell pattern such as db.*.latency,an invalid pattern matches nothing
+func GlobFilter(pattern string) MetricFilter {
+	return MetricFilterFunc(func(name MetricName, metric interface{}) bool {
+		matched, err := path.Match(pattern, name.Name)
+		return err == nil && matched
+	})
+}
+
+//RegexFilter matches the base names against the regular expression
+func RegexFilter(re *regexp.Regexp) MetricFilter {
+	return MetricFilterFunc(func(name MetricName, metric interface{}) bool {
+		return re.MatchString(name.Name)
+	})
+}
+
+//KindFilter matches the metrics of the given kinds
+func KindFilter(kinds ...MetricKind) MetricFilter {
+	return MetricFilterFunc(func(name MetricName, metric interface{}) bool {
+		kind := KindOf(metric)
+		for _, k := range kinds {
+			if k == kind {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+//TagFilter matches the metrics having the tag with the given value,or with any value if value is empty
+func TagFilter(key, value string) MetricFilter {
+	return MetricFilterFunc(func(name MetricName, metric interface{}) bool {
+		v, ok := name.Tag(key)
+		return ok && (value == "" || v == value)
+	})
+}
+
+//And matches the metrics matched by all the filters
+func And(filters ...MetricFilter) MetricFilter {
+	return MetricFilterFunc(func(name MetricName, metric interface{}) bool {
+		for _, f := range filters {
+			if !f.Matches(name, metric) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+//Or matches the metrics matched by any of the filters
+func Or(filters ...MetricFilter) MetricFilter {
+	return MetricFilterFunc(func(name MetricName, metric interface{}) bool {
+		for _, f := range filters {
+			if f.Matches(name, metric) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+//Not matches the metrics the filter doesn't match
+func Not(filter MetricFilter) MetricFilter {
+	return MetricFilterFunc(func(name MetricName, metric interface{}) bool {
+		return !filter.Matches(name, metric)
+	})
+}
+
+//EachFiltered calls the function for each metric of the registry matched by the filter,for each metric if the filter is nil
+func EachFiltered(r Registry, filter MetricFilter, f func(MetricName, interface{})) {
+	r.EachTagged(func(name MetricName, i interface{}) {
+		if filter == nil || filter.Matches(name, i) {
+			f(name, i)
+		}
+	})
+}
+
+//EachOfType calls the function for each metric of the registry of type T,such as mechanism.Timer
+func EachOfType[T any](r Registry, f func(string, T)) {
+	r.Each(func(name string, i interface{}) {
+		if metric, ok := i.(T); ok {
+			f(name, metric)
+		}
+	})
+}
+
+//the names of the registry sorted,shared by the Names of the registries
+func sortedNames(r Registry) []string {
+	var names []string
+	r.Each(func(name string, i interface{}) {
+		names = append(names, name)
+	})
+	sort.Strings(names)
+	return names
+}
+
+//the metrics matched by GlobFilter,shared by the Find of the registries
+func find(r Registry, pattern string) map[string]interface{} {
+	found := make(map[string]interface{})
+	EachFiltered(r, GlobFilter(pattern), func(name MetricName, i interface{}) {
+		found[name.String()] = i
+	})
+	return found
+}
@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/carbin-gun/awesome-metrics/metrics"
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+func filteredRegistry() Registry {
+	r := NewRegistry()
+	r.Register("db.latency", metrics.NewTimer())
+	r.Register("db.queries", metrics.NewCounter())
+	r.RegisterTagged(NewMetricName("http.requests", "method", "GET"), metrics.NewMeter())
+	r.RegisterTagged(NewMetricName("http.requests", "method", "POST"), metrics.NewMeter())
+	r.Register("uptime", &metrics.StandardGauge{})
+	return r
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter MetricFilter
+		want   []string
+	}{
+		{"nil", nil, []string{"db.latency", "db.queries", "http.requests{method=GET}", "http.requests{method=POST}", "uptime"}},
+		{"prefix", NamePrefixFilter("db."), []string{"db.latency", "db.queries"}},
+		{"glob", GlobFilter("*.requests"), []string{"http.requests{method=GET}", "http.requests{method=POST}"}},
+		{"invalid glob", GlobFilter("[db"), nil},
+		{"regex", RegexFilter(regexp.MustCompile(`^(db\.q|up)`)), []string{"db.queries", "uptime"}},
+		{"kind", KindFilter(KindTimer, KindGauge), []string{"db.latency", "uptime"}},
+		{"tag", TagFilter("method", ""), []string{"http.requests{method=GET}", "http.requests{method=POST}"}},
+		{"tag value", TagFilter("method", "POST"), []string{"http.requests{method=POST}"}},
+		{"and", And(NamePrefixFilter("db."), KindFilter(KindCounter)), []string{"db.queries"}},
+		{"empty and", And(), []string{"db.latency", "db.queries", "http.requests{method=GET}", "http.requests{method=POST}", "uptime"}},
+		{"or", Or(TagFilter("method", "GET"), KindFilter(KindGauge)), []string{"http.requests{method=GET}", "uptime"}},
+		{"empty or", Or(), nil},
+		{"not", Not(NamePrefixFilter("http")), []string{"db.latency", "db.queries", "uptime"}},
+		{"func", MetricFilterFunc(func(name MetricName, _ interface{}) bool { return len(name.Name) == 6 }), []string{"uptime"}},
+	}
+	r := filteredRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			r.EachMatching(tt.filter, func(name MetricName, _ interface{}) {
+				got = append(got, name.String())
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matched %v,want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalJsonFilter(t *testing.T) {
+	data, err := filteredRegistry().MarshalJsonWithOptions(output.Options{Filter: TagFilter("method", "")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for key := range payload {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if want := []string{"http.requests{method=GET}", "http.requests{method=POST}"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("MarshalJson() holds %v,want %v", keys, want)
+	}
+}
@@ -0,0 +1,273 @@
+package registry
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//FieldKind is the role of a field in a metric frame
+type FieldKind int
+
+const (
+	StatField       FieldKind = iota //count,value,sum,min,max,mean or stddev
+	PercentileField                  //a percentile such as p99,Quantile is set
+	BucketField                      //the cumulative count of a fixed bucket,Bound is set
+	RateField                        //rate1,rate5,rate15 or rate_mean
+)
+
+//Field is one value of a metric in a report frame
+type Field struct {
+	Kind     FieldKind
+	Name     string  //count,value,sum,min,max,mean,stddev,a percentile key such as p99,bucket,rate1,rate5,rate15 or rate_mean
+	Integer  bool    //the value is the integer Int,Float otherwise
+	Int      int64   //value of the integer fields
+	Float    float64 //value of the other fields
+	Recorded bool    //the float is recorded as is rather than computed or converted,such as the value of a Gauge64
+	Unit     string  //suffix of the converted durations and rates such as ms or /s,empty for plain values
+	Quantile float64 //quantile of the percentile fields,such as 0.99
+	Bound    float64 //upper bound of the bucket fields,in the duration unit for timers
+}
+
+//Value returns the value of the field as a float64
+func (f Field) Value() float64 {
+	if f.Integer {
+		return float64(f.Int)
+	}
+	return f.Float
+}
+
+//MetricFrame is the normalized report of a metric:its name,kind and fields in the order reporters write them
+type MetricFrame struct {
+	Name        MetricName
+	Key         string //registry key of the metric,Name.String()
+	Kind        MetricKind
+	Fields      []Field
+	Err         error             //result of the last check of healthchecks
+	Description MetricDescription //zero if the registry doesn't describe the metric
+}
+
+//Field returns the first field with the name
+func (m *MetricFrame) Field(name string) (Field, bool) {
+	for _, f := range m.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+//Bucketed reports whether the metric has fixed buckets
+func (m *MetricFrame) Bucketed() bool {
+	for _, f := range m.Fields {
+		if f.Kind == BucketField {
+			return true
+		}
+	}
+	return false
+}
+
+//Frame is what a reporter reports in a tick,built once from the registry and encoded by the reporter.
+//MarshalJson of the registries encodes it too,so that every output names and converts the values the same way
+type Frame struct {
+	Time    time.Time
+	Prefix  string        //universal prefix of the registry followed by a point,empty if it has none
+	Metrics []MetricFrame //sorted by key
+}
+
+//MarshalJSON encodes the metrics keyed by their names prefixed by the prefix of the frame,such as
+//{"app.t":{"kind":"timer","count":2,"sum":3,...,"p50":1,"rate1":30,...,"duration_unit":"ms","rate_unit":"/min"}}.
+//The fields are keyed by their names,the buckets by their upper bound under "buckets"
+func (f *Frame) MarshalJSON() ([]byte, error) {
+	data := make(map[string]map[string]interface{}, len(f.Metrics))
+	for i := range f.Metrics {
+		data[f.Prefix+f.Metrics[i].Key] = f.Metrics[i].jsonValues()
+	}
+	return json.Marshal(data)
+}
+
+func (m *MetricFrame) jsonValues() map[string]interface{} {
+	values := map[string]interface{}{"kind": m.Kind.String()}
+	if len(m.Name.Tags) > 0 {
+		tags := make(map[string]string)
+		for _, t := range m.Name.Tags {
+			tags[t.Key] = t.Value
+		}
+		values["tags"] = tags
+	}
+	if m.Kind == KindHealthcheck {
+		values["error"] = nil
+		if m.Err != nil {
+			values["error"] = m.Err.Error()
+		}
+	}
+	var buckets map[string]int64
+	for _, f := range m.Fields {
+		switch {
+		case f.Kind == BucketField:
+			if buckets == nil {
+				buckets = make(map[string]int64)
+				values["buckets"] = buckets
+			}
+			le := "+Inf"
+			if !math.IsInf(f.Bound, 1) {
+				le = strconv.FormatFloat(f.Bound, 'f', -1, 64)
+			}
+			buckets[le] = f.Int
+			continue
+		case f.Kind == RateField:
+			values["rate_unit"] = f.Unit
+		case f.Unit != "":
+			values["duration_unit"] = f.Unit
+		}
+		if f.Integer {
+			values[f.Name] = f.Int
+		} else {
+			values[f.Name] = f.Float
+		}
+	}
+	if d := m.Description; d.Description != "" {
+		values["description"] = d.Description
+	}
+	if d := m.Description; d.Unit != "" {
+		values["unit"] = d.Unit
+	}
+	if m.Description.Monotonic {
+		values["monotonic"] = true
+	}
+	return values
+}
+
+//NewFrame reads the metrics of the registry passing the filter of the options.Percentiles are the ones of the metric or
+//of the options,durations are converted to the duration unit and rates to the rate unit of the options
+func NewFrame(r Registry, o output.Options, now time.Time) *Frame {
+	f := &Frame{Time: now, Prefix: framePrefix(r)}
+	du, ds, rs := float64(o.Durations()), o.DurationSuffix(), o.RateSuffix()
+	EachFiltered(r, o.Filter, func(n MetricName, i interface{}) {
+		kind := KindOf(i)
+		if kind == KindUnknown {
+			return
+		}
+		key := n.String()
+		m := MetricFrame{Name: n, Key: key, Kind: kind}
+		m.Description, _ = r.Describe(key)
+		percentiles := percentilesFor(r, key, o.Percentiles)
+		switch metric := i.(type) {
+		case mechanism.Counter:
+			m.Fields = []Field{intField("count", metric.Count())}
+		case mechanism.Gauge:
+			m.Fields = []Field{intField("value", metric.Value())}
+		case mechanism.Gauge64:
+			m.Fields = []Field{recordedField("value", metric.Value())}
+		case mechanism.Histogram:
+			h := metric.Snapshot()
+			m.Fields = []Field{
+				intField("count", h.Count()),
+				intField("sum", h.Sum()),
+				intField("min", h.Min()),
+				intField("max", h.Max()),
+				floatField("mean", h.Mean(), ""),
+				floatField("stddev", h.StdDev(), ""),
+			}
+			m.Fields = appendPercentiles(m.Fields, h, percentiles, 1, "")
+			m.Fields = appendBuckets(m.Fields, h, 1)
+		case mechanism.HistogramFloat64:
+			h := metric.Snapshot()
+			m.Fields = []Field{
+				intField("count", h.Count()),
+				recordedField("sum", h.Sum()),
+				recordedField("min", h.Min()),
+				recordedField("max", h.Max()),
+				floatField("mean", h.Mean(), ""),
+				floatField("stddev", h.StdDev(), ""),
+			}
+			m.Fields = appendPercentiles(m.Fields, h, percentiles, 1, "")
+		case mechanism.Meter:
+			m.Fields = appendRates([]Field{intField("count", metric.Count())}, metric, o, rs)
+		case mechanism.Timer:
+			t := metric.Snapshot()
+			m.Fields = []Field{
+				intField("count", t.Count()),
+				floatField("sum", float64(t.Sum())/du, ds),
+				floatField("min", float64(t.Min())/du, ds),
+				floatField("max", float64(t.Max())/du, ds),
+				floatField("mean", t.Mean()/du, ds),
+				floatField("stddev", t.StdDev()/du, ds),
+			}
+			m.Fields = appendPercentiles(m.Fields, t, percentiles, du, ds)
+			m.Fields = appendBuckets(m.Fields, t, du)
+			m.Fields = appendRates(m.Fields, metric, o, rs)
+		case mechanism.Healthcheck:
+			m.Err = metric.Error()
+		}
+		f.Metrics = append(f.Metrics, m)
+	})
+	sort.Slice(f.Metrics, func(i, j int) bool { return f.Metrics[i].Key < f.Metrics[j].Key })
+	return f
+}
+
+//the universal prefix of the registry followed by a point,empty if it has none
+func framePrefix(r Registry) string {
+	if prefix := r.Prefix(); prefix != "" {
+		return prefix + "."
+	}
+	return ""
+}
+
+//percentiles to report for the named metric,the ones registered with the metric win over the given ones
+func percentilesFor(r Registry, name string, percentiles []float64) []float64 {
+	if metricPercentiles := r.Options(name).Percentiles; len(metricPercentiles) > 0 {
+		return metricPercentiles
+	}
+	if len(percentiles) > 0 {
+		return percentiles
+	}
+	return output.DefaultPercentiles
+}
+
+func intField(name string, value int64) Field {
+	return Field{Kind: StatField, Name: name, Integer: true, Int: value}
+}
+
+func floatField(name string, value float64, unit string) Field {
+	return Field{Kind: StatField, Name: name, Float: value, Unit: unit}
+}
+
+func recordedField(name string, value float64) Field {
+	return Field{Kind: StatField, Name: name, Float: value, Recorded: true}
+}
+
+//append the percentiles of the snapshot,values are divided by du and followed by unit
+func appendPercentiles(fields []Field, snapshot output.Percentiled, percentiles []float64, du float64, unit string) []Field {
+	for _, p := range percentiles {
+		fields = append(fields, Field{Kind: PercentileField, Name: output.PercentileKey(p), Float: snapshot.Value(p) / du, Unit: unit, Quantile: p})
+	}
+	return fields
+}
+
+//append the cumulative buckets if the snapshot is backed by a bucket histogram,bounds are divided by du
+func appendBuckets(fields []Field, snapshot output.Snapshot, du float64) []Field {
+	bucketed, ok := snapshot.(output.Bucketed)
+	if !ok {
+		return fields
+	}
+	for _, b := range bucketed.Buckets() {
+		fields = append(fields, Field{Kind: BucketField, Name: "bucket", Integer: true, Int: b.Count, Bound: b.UpperBound / du})
+	}
+	return fields
+}
+
+//append the rates converted to the rate unit of the options
+func appendRates(fields []Field, m output.Metered, o output.Options, unit string) []Field {
+	return append(fields,
+		Field{Kind: RateField, Name: "rate1", Float: o.ConvertRate(m.Rate1()), Unit: unit},
+		Field{Kind: RateField, Name: "rate5", Float: o.ConvertRate(m.Rate5()), Unit: unit},
+		Field{Kind: RateField, Name: "rate15", Float: o.ConvertRate(m.Rate15()), Unit: unit},
+		Field{Kind: RateField, Name: "rate_mean", Float: o.ConvertRate(m.RateMean()), Unit: unit},
+	)
+}
@@ -0,0 +1,179 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+//DefaultRejectionsName is the name of the counter of the registrations rejected by the limits
+const DefaultRejectionsName = "registry.rejections"
+
+//DefaultMetricBytes is the estimated memory of the metrics which don't implement mechanism.MemoryEstimator
+const DefaultMetricBytes int64 = 128
+
+//LimitAction is what GetOrRegister returns when a limit is hit
+type LimitAction int
+
+const (
+	LimitReject LimitAction = iota //the unregistered metric,the typed helpers return the *LimitExceededError
+	LimitNoop                      //a shared no-op metric of the same kind
+)
+
+//Limits bound the metrics held by a registry,a zero value means no limit
+type Limits struct {
+	MaxMetrics     int            //metrics in the registry
+	MaxPerPrefix   map[string]int //metrics whose name starts with the prefix,such as "customer."
+	MaxBytes       int64          //estimated memory,counting the reservoir sizes
+	Action         LimitAction
+	RejectionsName string //name of the rejection counter registered in the registry,DefaultRejectionsName if empty
+}
+
+//LimitExceededError is returned when registering a metric would exceed a limit of the registry
+type LimitExceededError struct {
+	Name  string
+	Limit string //description of the exceeded limit
+	Max   int64
+	//Fallback is the shared no-op metric to use instead with LimitNoop,nil otherwise
+	Fallback interface{}
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("limit exceeded: %s can't be registered,%s is %d", e.Name, e.Limit, e.Max)
+}
+
+//one shared no-op metric per kind
+var noopMetrics = map[MetricKind]interface{}{
+	KindCounter:          metrics.NoopCounter{},
+	KindGauge:            metrics.NoopGauge{},
+	KindGauge64:          metrics.NoopGauge64{},
+	KindHistogram:        metrics.NoopHistogram{},
+	KindHistogramFloat64: metrics.NoopHistogramFloat64{},
+	KindMeter:            metrics.NoopMeter{},
+	KindTimer:            metrics.NoopTimer{},
+	KindHealthcheck:      metrics.NoopHealthcheck{},
+}
+
+//maxRememberedRejections bounds the rejected names remembered by a limiter,beyond it they are forgotten and counted again
+const maxRememberedRejections = 10000
+
+//rejectionsCounter hides the last update of the counter,so that expiry never sweeps the rejections however idle they are
+type rejectionsCounter struct {
+	mechanism.Counter
+}
+
+//NewLimitedRegistry creates a registry rejecting the registrations beyond the limits
+func NewLimitedRegistry(limits Limits) Registry {
+	return NewLimitedPrefixRegistry("", limits)
+}
+
+//NewLimitedPrefixRegistry is just like NewLimitedRegistry,with the universal prefix of all the metrics-keys
+func NewLimitedPrefixRegistry(prefix string, limits Limits) Registry {
+	r := NewPrefixRegistry(prefix).(*StandardRegistry)
+	if limits.RejectionsName == "" {
+		limits.RejectionsName = DefaultRejectionsName
+	}
+	l := &limiter{
+		limits:     limits,
+		perPrefix:  make(map[string]int),
+		rejected:   make(map[string]bool),
+		rejections: rejectionsCounter{metrics.NewCounter()},
+	}
+	r.register(MetricName{Name: limits.RejectionsName}, l.rejections, nil)
+	r.limiter = l
+	return r
+}
+
+//Rejections returns the counter of the metrics rejected by the limits,nil if the registry has none.
+//A name is counted once however many times it's registered,until a metric is unregistered and frees some room
+func (r *StandardRegistry) Rejections() mechanism.Counter {
+	if r.limiter == nil {
+		return nil
+	}
+	return r.limiter.rejections
+}
+
+//limiter tracks the usage of a registry against its limits
+type limiter struct {
+	limits     Limits
+	mutex      sync.Mutex
+	count      int
+	perPrefix  map[string]int
+	bytes      int64
+	rejected   map[string]bool //names already counted by rejections
+	rejections mechanism.Counter
+}
+
+func estimateBytes(i interface{}) int64 {
+	if e, ok := i.(mechanism.MemoryEstimator); ok {
+		return e.EstimatedBytes()
+	}
+	return DefaultMetricBytes
+}
+
+//reserve accounts for the metric,or returns a *LimitExceededError if it doesn't fit.
+//The rejections counter itself is never limited,like release never gives it back
+func (l *limiter) reserve(name string, i interface{}) error {
+	if name == l.limits.RejectionsName {
+		return nil
+	}
+	bytes := estimateBytes(i)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var err *LimitExceededError
+	if l.limits.MaxMetrics > 0 && l.count >= l.limits.MaxMetrics {
+		err = &LimitExceededError{Name: name, Limit: "max metrics", Max: int64(l.limits.MaxMetrics)}
+	}
+	for prefix, max := range l.limits.MaxPerPrefix {
+		if err == nil && max > 0 && strings.HasPrefix(name, prefix) && l.perPrefix[prefix] >= max {
+			err = &LimitExceededError{Name: name, Limit: "max metrics under " + prefix, Max: int64(max)}
+		}
+	}
+	if err == nil && l.limits.MaxBytes > 0 && l.bytes+bytes > l.limits.MaxBytes {
+		err = &LimitExceededError{Name: name, Limit: "memory budget in bytes", Max: l.limits.MaxBytes}
+	}
+	if err != nil {
+		if !l.rejected[name] {
+			if len(l.rejected) >= maxRememberedRejections {
+				l.rejected = make(map[string]bool)
+			}
+			l.rejected[name] = true
+			l.rejections.Inc(1)
+		}
+		if l.limits.Action == LimitNoop {
+			err.Fallback = noopMetrics[KindOf(i)]
+		}
+		return err
+	}
+	l.count++
+	for prefix := range l.limits.MaxPerPrefix {
+		if strings.HasPrefix(name, prefix) {
+			l.perPrefix[prefix]++
+		}
+	}
+	l.bytes += bytes
+	return nil
+}
+
+//release gives back what reserve accounted for the metric
+func (l *limiter) release(name string, i interface{}) {
+	if name == l.limits.RejectionsName {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.count--
+	for prefix := range l.limits.MaxPerPrefix {
+		if strings.HasPrefix(name, prefix) {
+			l.perPrefix[prefix]--
+		}
+	}
+	l.bytes -= estimateBytes(i)
+	//the rejected names may fit now,they are counted again if they don't
+	if len(l.rejected) > 0 {
+		l.rejected = make(map[string]bool)
+	}
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+func TestRejectionsCountNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		action LimitAction
+	}{
+		{"reject", LimitReject},
+		{"noop", LimitNoop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewLimitedRegistry(Limits{MaxMetrics: 1, Action: tt.action})
+			rejections := r.(*StandardRegistry).Rejections()
+			r.GetOrRegister("a", metrics.NewCounter)
+			for i := 0; i < 3; i++ {
+				r.GetOrRegister("b", metrics.NewCounter)
+				r.GetOrRegister("c", metrics.NewCounter)
+			}
+			if count := rejections.Count(); count != 2 {
+				t.Fatalf("rejections = %d after rejecting b and c 3 times, want 2", count)
+			}
+			r.Unregister("a")
+			r.GetOrRegister("b", metrics.NewCounter)
+			r.GetOrRegister("c", metrics.NewCounter)
+			if count := rejections.Count(); count != 3 {
+				t.Fatalf("rejections = %d after rejecting c once more, want 3", count)
+			}
+		})
+	}
+}
+
+func TestLimitNoopFallbacks(t *testing.T) {
+	tests := []struct {
+		kind   MetricKind
+		create func() interface{}
+	}{
+		{KindCounter, func() interface{} { return metrics.NewCounter() }},
+		{KindGauge, func() interface{} { return &metrics.StandardGauge{} }},
+		{KindGauge64, func() interface{} { return &metrics.StandardGauge64{} }},
+		{KindHistogram, func() interface{} { return metrics.NewHistogram(metrics.NewUniformReservoir(10)) }},
+		{KindMeter, func() interface{} { return metrics.NewMeter() }},
+		{KindTimer, func() interface{} { return metrics.NewTimer() }},
+		{KindHealthcheck, func() interface{} { return metrics.NewHealthcheck(func(mechanism.Healthcheck) {}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			r := NewLimitedRegistry(Limits{MaxMetrics: 1, Action: LimitNoop})
+			r.Register("first", metrics.NewCounter())
+			i := r.GetOrRegister("rejected", tt.create)
+			if i == nil || KindOf(i) != tt.kind {
+				t.Fatalf("GetOrRegister() = %#v, want a no-op %s", i, tt.kind)
+			}
+			if i != noopMetrics[tt.kind] {
+				t.Errorf("GetOrRegister() = %#v, want the shared no-op %#v", i, noopMetrics[tt.kind])
+			}
+		})
+	}
+}
+
+func TestRejectionsAreProtected(t *testing.T) {
+	r := NewLimitedRegistry(Limits{MaxMetrics: 1})
+	rejections := r.(*StandardRegistry).Rejections()
+	r.Register("a", metrics.NewCounter())
+	s := NewExpirySweeper(r, ExpiryPolicy{IdleTimeout: time.Minute})
+	defer s.Stop()
+	now := time.Now()
+	s.Sweep(now)
+	s.Sweep(now.Add(time.Hour))
+	if r.Get(DefaultRejectionsName) == nil {
+		t.Fatal("expiry swept the rejections")
+	}
+	r.UnregisterAll()
+	r.Register("b", metrics.NewCounter())
+	if err := r.Register(DefaultRejectionsName, rejections); err != nil {
+		t.Fatalf("registering the rejections again = %v, want no limit", err)
+	}
+	if err := r.Register("c", metrics.NewCounter()); err == nil {
+		t.Fatal("the registry holds 2 metrics,want a limit of 1 besides the rejections")
+	}
+}
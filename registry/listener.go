@@ -0,0 +1,231 @@
+package registry
+
+import "github.com/carbin-gun/awesome-metrics/mechanism"
+
+//RegistryListener is notified when metrics are added to or removed from a registry.
+//Callbacks may be invoked concurrently from the goroutines registering metrics,without any lock of the registry held,
+//so they may register and unregister metrics.
+type RegistryListener interface {
+	OnCounterAdded(name string, counter mechanism.Counter)
+	OnCounterRemoved(name string, counter mechanism.Counter)
+	OnGaugeAdded(name string, gauge mechanism.Gauge)
+	OnGaugeRemoved(name string, gauge mechanism.Gauge)
+	OnGauge64Added(name string, gauge mechanism.Gauge64)
+	OnGauge64Removed(name string, gauge mechanism.Gauge64)
+	OnHistogramAdded(name string, histogram mechanism.Histogram)
+	OnHistogramRemoved(name string, histogram mechanism.Histogram)
+	OnHistogramFloat64Added(name string, histogram mechanism.HistogramFloat64)
+	OnHistogramFloat64Removed(name string, histogram mechanism.HistogramFloat64)
+	OnMeterAdded(name string, meter mechanism.Meter)
+	OnMeterRemoved(name string, meter mechanism.Meter)
+	OnTimerAdded(name string, timer mechanism.Timer)
+	OnTimerRemoved(name string, timer mechanism.Timer)
+	OnHealthcheckAdded(name string, healthcheck mechanism.Healthcheck)
+	OnHealthcheckRemoved(name string, healthcheck mechanism.Healthcheck)
+}
+
+//RegistryListenerBase implements every callback as a no-op,embed it to implement only the interesting ones
+type RegistryListenerBase struct{}
+
+func (RegistryListenerBase) OnCounterAdded(string, mechanism.Counter)                     {}
+func (RegistryListenerBase) OnCounterRemoved(string, mechanism.Counter)                   {}
+func (RegistryListenerBase) OnGaugeAdded(string, mechanism.Gauge)                         {}
+func (RegistryListenerBase) OnGaugeRemoved(string, mechanism.Gauge)                       {}
+func (RegistryListenerBase) OnGauge64Added(string, mechanism.Gauge64)                     {}
+func (RegistryListenerBase) OnGauge64Removed(string, mechanism.Gauge64)                   {}
+func (RegistryListenerBase) OnHistogramAdded(string, mechanism.Histogram)                 {}
+func (RegistryListenerBase) OnHistogramRemoved(string, mechanism.Histogram)               {}
+func (RegistryListenerBase) OnHistogramFloat64Added(string, mechanism.HistogramFloat64)   {}
+func (RegistryListenerBase) OnHistogramFloat64Removed(string, mechanism.HistogramFloat64) {}
+func (RegistryListenerBase) OnMeterAdded(string, mechanism.Meter)                         {}
+func (RegistryListenerBase) OnMeterRemoved(string, mechanism.Meter)                       {}
+func (RegistryListenerBase) OnTimerAdded(string, mechanism.Timer)                         {}
+func (RegistryListenerBase) OnTimerRemoved(string, mechanism.Timer)                       {}
+func (RegistryListenerBase) OnHealthcheckAdded(string, mechanism.Healthcheck)             {}
+func (RegistryListenerBase) OnHealthcheckRemoved(string, mechanism.Healthcheck)           {}
+
+//call the added callback matching the type of the metric
+func notifyAdded(l RegistryListener, name string, i interface{}) {
+	switch metric := i.(type) {
+	case mechanism.Counter:
+		l.OnCounterAdded(name, metric)
+	case mechanism.Gauge:
+		l.OnGaugeAdded(name, metric)
+	case mechanism.Gauge64:
+		l.OnGauge64Added(name, metric)
+	case mechanism.Histogram:
+		l.OnHistogramAdded(name, metric)
+	case mechanism.HistogramFloat64:
+		l.OnHistogramFloat64Added(name, metric)
+	case mechanism.Meter:
+		l.OnMeterAdded(name, metric)
+	case mechanism.Timer:
+		l.OnTimerAdded(name, metric)
+	case mechanism.Healthcheck:
+		l.OnHealthcheckAdded(name, metric)
+	}
+}
+
+//call the removed callback matching the type of the metric
+func notifyRemoved(l RegistryListener, name string, i interface{}) {
+	switch metric := i.(type) {
+	case mechanism.Counter:
+		l.OnCounterRemoved(name, metric)
+	case mechanism.Gauge:
+		l.OnGaugeRemoved(name, metric)
+	case mechanism.Gauge64:
+		l.OnGauge64Removed(name, metric)
+	case mechanism.Histogram:
+		l.OnHistogramRemoved(name, metric)
+	case mechanism.HistogramFloat64:
+		l.OnHistogramFloat64Removed(name, metric)
+	case mechanism.Meter:
+		l.OnMeterRemoved(name, metric)
+	case mechanism.Timer:
+		l.OnTimerRemoved(name, metric)
+	case mechanism.Healthcheck:
+		l.OnHealthcheckRemoved(name, metric)
+	}
+}
+
+//scopedListener forwards the events of the metrics of a scope to a listener,with the names relative to the scope
+type scopedListener struct {
+	scope    string //full scope path,such as db.pool
+	listener RegistryListener
+}
+
+func (s scopedListener) OnCounterAdded(name string, counter mechanism.Counter) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnCounterAdded(n, counter)
+	}
+}
+func (s scopedListener) OnCounterRemoved(name string, counter mechanism.Counter) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnCounterRemoved(n, counter)
+	}
+}
+func (s scopedListener) OnGaugeAdded(name string, gauge mechanism.Gauge) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnGaugeAdded(n, gauge)
+	}
+}
+func (s scopedListener) OnGaugeRemoved(name string, gauge mechanism.Gauge) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnGaugeRemoved(n, gauge)
+	}
+}
+func (s scopedListener) OnGauge64Added(name string, gauge mechanism.Gauge64) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnGauge64Added(n, gauge)
+	}
+}
+func (s scopedListener) OnGauge64Removed(name string, gauge mechanism.Gauge64) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnGauge64Removed(n, gauge)
+	}
+}
+func (s scopedListener) OnHistogramAdded(name string, histogram mechanism.Histogram) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnHistogramAdded(n, histogram)
+	}
+}
+func (s scopedListener) OnHistogramRemoved(name string, histogram mechanism.Histogram) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnHistogramRemoved(n, histogram)
+	}
+}
+func (s scopedListener) OnHistogramFloat64Added(name string, histogram mechanism.HistogramFloat64) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnHistogramFloat64Added(n, histogram)
+	}
+}
+func (s scopedListener) OnHistogramFloat64Removed(name string, histogram mechanism.HistogramFloat64) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnHistogramFloat64Removed(n, histogram)
+	}
+}
+func (s scopedListener) OnMeterAdded(name string, meter mechanism.Meter) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnMeterAdded(n, meter)
+	}
+}
+func (s scopedListener) OnMeterRemoved(name string, meter mechanism.Meter) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnMeterRemoved(n, meter)
+	}
+}
+func (s scopedListener) OnTimerAdded(name string, timer mechanism.Timer) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnTimerAdded(n, timer)
+	}
+}
+func (s scopedListener) OnTimerRemoved(name string, timer mechanism.Timer) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnTimerRemoved(n, timer)
+	}
+}
+func (s scopedListener) OnHealthcheckAdded(name string, healthcheck mechanism.Healthcheck) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnHealthcheckAdded(n, healthcheck)
+	}
+}
+func (s scopedListener) OnHealthcheckRemoved(name string, healthcheck mechanism.Healthcheck) {
+	if n, ok := trimScope(s.scope, name); ok {
+		s.listener.OnHealthcheckRemoved(n, healthcheck)
+	}
+}
+
+//mountedListener forwards the events of a registry mounted in a CompositeRegistry to a listener,with the full names
+type mountedListener struct {
+	namespace string
+	listener  RegistryListener
+}
+
+func (s mountedListener) OnCounterAdded(name string, counter mechanism.Counter) {
+	s.listener.OnCounterAdded(joinNamespace(s.namespace, name), counter)
+}
+func (s mountedListener) OnCounterRemoved(name string, counter mechanism.Counter) {
+	s.listener.OnCounterRemoved(joinNamespace(s.namespace, name), counter)
+}
+func (s mountedListener) OnGaugeAdded(name string, gauge mechanism.Gauge) {
+	s.listener.OnGaugeAdded(joinNamespace(s.namespace, name), gauge)
+}
+func (s mountedListener) OnGaugeRemoved(name string, gauge mechanism.Gauge) {
+	s.listener.OnGaugeRemoved(joinNamespace(s.namespace, name), gauge)
+}
+func (s mountedListener) OnGauge64Added(name string, gauge mechanism.Gauge64) {
+	s.listener.OnGauge64Added(joinNamespace(s.namespace, name), gauge)
+}
+func (s mountedListener) OnGauge64Removed(name string, gauge mechanism.Gauge64) {
+	s.listener.OnGauge64Removed(joinNamespace(s.namespace, name), gauge)
+}
+func (s mountedListener) OnHistogramAdded(name string, histogram mechanism.Histogram) {
+	s.listener.OnHistogramAdded(joinNamespace(s.namespace, name), histogram)
+}
+func (s mountedListener) OnHistogramRemoved(name string, histogram mechanism.Histogram) {
+	s.listener.OnHistogramRemoved(joinNamespace(s.namespace, name), histogram)
+}
+func (s mountedListener) OnHistogramFloat64Added(name string, histogram mechanism.HistogramFloat64) {
+	s.listener.OnHistogramFloat64Added(joinNamespace(s.namespace, name), histogram)
+}
+func (s mountedListener) OnHistogramFloat64Removed(name string, histogram mechanism.HistogramFloat64) {
+	s.listener.OnHistogramFloat64Removed(joinNamespace(s.namespace, name), histogram)
+}
+func (s mountedListener) OnMeterAdded(name string, meter mechanism.Meter) {
+	s.listener.OnMeterAdded(joinNamespace(s.namespace, name), meter)
+}
+func (s mountedListener) OnMeterRemoved(name string, meter mechanism.Meter) {
+	s.listener.OnMeterRemoved(joinNamespace(s.namespace, name), meter)
+}
+func (s mountedListener) OnTimerAdded(name string, timer mechanism.Timer) {
+	s.listener.OnTimerAdded(joinNamespace(s.namespace, name), timer)
+}
+func (s mountedListener) OnTimerRemoved(name string, timer mechanism.Timer) {
+	s.listener.OnTimerRemoved(joinNamespace(s.namespace, name), timer)
+}
+func (s mountedListener) OnHealthcheckAdded(name string, healthcheck mechanism.Healthcheck) {
+	s.listener.OnHealthcheckAdded(joinNamespace(s.namespace, name), healthcheck)
+}
+func (s mountedListener) OnHealthcheckRemoved(name string, healthcheck mechanism.Healthcheck) {
+	s.listener.OnHealthcheckRemoved(joinNamespace(s.namespace, name), healthcheck)
+}
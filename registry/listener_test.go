@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+//registers a gauge next to every counter added,while another listener is being added
+type derivingListener struct {
+	RegistryListenerBase
+	r Registry
+}
+
+func (l *derivingListener) OnCounterAdded(name string, _ mechanism.Counter) {
+	added := make(chan struct{})
+	go func() {
+		l.r.AddListener(&RegistryListenerBase{})
+		close(added)
+	}()
+	time.Sleep(10 * time.Millisecond) //let AddListener wait for the lock
+	l.r.Register(name+".derived", metrics.NewFrozenGauge(1))
+	<-added
+}
+
+func TestListenerMayRegister(t *testing.T) {
+	r := NewRegistry()
+	r.AddListener(&derivingListener{r: r})
+	done := make(chan struct{})
+	go func() {
+		r.Register("requests", metrics.NewCounter())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("registering from a listener deadlocked")
+	}
+	if r.Get("requests.derived") == nil {
+		t.Fatal("the metric registered by the listener is missing")
+	}
+}
+
+//counts the added and removed counters
+type countingListener struct {
+	RegistryListenerBase
+	added, removed int
+}
+
+func (l *countingListener) OnCounterAdded(string, mechanism.Counter)   { l.added++ }
+func (l *countingListener) OnCounterRemoved(string, mechanism.Counter) { l.removed++ }
+
+func TestAddListenerReplaysRegisteredMetrics(t *testing.T) {
+	r := NewRegistry()
+	r.Register("a", metrics.NewCounter())
+	r.Register("b", metrics.NewCounter())
+	l := &countingListener{}
+	r.AddListener(l)
+	if l.added != 2 {
+		t.Fatalf("replayed %d counters, want 2", l.added)
+	}
+	r.Register("c", metrics.NewCounter())
+	r.Unregister("a")
+	r.RemoveListener(l)
+	r.Unregister("b")
+	if l.added != 3 || l.removed != 1 {
+		t.Fatalf("added %d and removed %d counters, want 3 and 1", l.added, l.removed)
+	}
+}
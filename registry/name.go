@@ -0,0 +1,128 @@
+package registry
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//Tag is a dimension of a metric name,such as method=GET
+type Tag = output.Tag
+
+//MetricName is a base name plus ordered tags,such as http.latency{method=GET,status=200}
+type MetricName = output.MetricName
+
+//NewMetricName builds a MetricName from the base name and alternating tag keys and values
+func NewMetricName(name string, keyValues ...string) MetricName {
+	return output.NewMetricName(name, keyValues...)
+}
+
+//NamePolicy is what a registry does with the names which aren't valid,see ValidName
+type NamePolicy int
+
+const (
+	NameAccept    NamePolicy = iota //register the names as given
+	NameReject                      //return an *InvalidNameError
+	NameNormalize                   //register under the name returned by NormalizeName
+)
+
+func validNameRune(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == ':'
+}
+
+func validTagKeyRune(i int, c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || i > 0 && c >= '0' && c <= '9'
+}
+
+//ValidName reports whether the name is made of non-empty segments separated by points,
+//whose characters are ASCII letters,digits,'_','-' or ':'
+func ValidName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, segment := range strings.Split(name, ".") {
+		if segment == "" {
+			return false
+		}
+		for _, c := range segment {
+			if !validNameRune(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//ValidTagKey reports whether the tag key is made of ASCII letters,digits and '_',not starting with a digit
+func ValidTagKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		if !validTagKeyRune(i, c) {
+			return false
+		}
+	}
+	return true
+}
+
+//NormalizeName replaces the invalid characters of the name by '_' and drops its empty segments
+func NormalizeName(name string) string {
+	var segments []string
+	for _, segment := range strings.Split(name, ".") {
+		if segment == "" {
+			continue
+		}
+		segments = append(segments, strings.Map(func(c rune) rune {
+			if validNameRune(c) {
+				return c
+			}
+			return '_'
+		}, segment))
+	}
+	if len(segments) == 0 {
+		return "_"
+	}
+	return strings.Join(segments, ".")
+}
+
+//NormalizeTagKey replaces the invalid characters of the tag key by '_'
+func NormalizeTagKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	i := -1
+	return strings.Map(func(c rune) rune {
+		i++
+		if validTagKeyRune(i, c) {
+			return c
+		}
+		return '_'
+	}, key)
+}
+
+//apply the policy to the base name and the tag keys,tag values are left to the reporters
+func applyNamePolicy(policy NamePolicy, name MetricName) (MetricName, error) {
+	switch policy {
+	case NameReject:
+		if !ValidName(name.Name) {
+			return name, &InvalidNameError{Name: name.String(), Reason: "invalid name " + strconv.Quote(name.Name)}
+		}
+		for _, t := range name.Tags {
+			if !ValidTagKey(t.Key) {
+				return name, &InvalidNameError{Name: name.String(), Reason: "invalid tag key " + strconv.Quote(t.Key)}
+			}
+		}
+	case NameNormalize:
+		normalized := MetricName{Name: NormalizeName(name.Name)}
+		if len(name.Tags) > 0 {
+			normalized.Tags = make([]Tag, len(name.Tags))
+			for i, t := range name.Tags {
+				normalized.Tags[i] = Tag{Key: NormalizeTagKey(t.Key), Value: t.Value}
+			}
+		}
+		return normalized, nil
+	}
+	return name, nil
+}
@@ -0,0 +1,155 @@
+package registry
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+func TestNormalizedLookups(t *testing.T) {
+	const name, normalized = "http requests..total", "http_requests.total"
+	tests := []struct {
+		name   string
+		lookup func(r Registry) bool //whether the metric registered as name is found
+	}{
+		{"Get", func(r Registry) bool { return r.Get(name) != nil }},
+		{"GetOrRegister", func(r Registry) bool {
+			_, isCounter := r.GetOrRegister(name, metrics.NewMeter).(*metrics.StandardCounter)
+			return isCounter
+		}},
+		{"Options", func(r Registry) bool { return r.Options(name).Description == "requests" }},
+		{"Describe", func(r Registry) bool {
+			d, ok := r.Describe(name)
+			return ok && d.Description == "requests" && d.Name.Name == normalized
+		}},
+		{"GetTagged", func(r Registry) bool { return r.GetTagged(MetricName{Name: name}) != nil }},
+		{"Unregister", func(r Registry) bool {
+			r.Unregister(name)
+			return r.Get(normalized) == nil
+		}},
+		{"UnregisterTagged", func(r Registry) bool {
+			r.UnregisterTagged(MetricName{Name: name})
+			return r.Get(normalized) == nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, scoped := range []bool{false, true} {
+				root := NewRegistry().(*StandardRegistry)
+				root.SetNamePolicy(NameNormalize)
+				var r Registry = root
+				if scoped {
+					r = root.Scope("api")
+				}
+				if err := r.Register(name, metrics.NewCounter(), WithDescription("requests")); err != nil {
+					t.Fatal(err)
+				}
+				if r.Get(normalized) == nil {
+					t.Fatalf("the metric isn't registered under %q", normalized)
+				}
+				if !tt.lookup(r) {
+					t.Errorf("%s(%q) doesn't find the metric registered as %q,scoped: %v", tt.name, name, normalized, scoped)
+				}
+			}
+		})
+	}
+}
+
+//a plain name can't hold the key of a tagged name,whatever the policy
+func TestBraceInPlainName(t *testing.T) {
+	tests := []struct {
+		policy NamePolicy
+		want   string //registered key,empty if the name is rejected
+	}{
+		{NameAccept, ""},
+		{NameReject, ""},
+		{NameNormalize, "a_b_c_"},
+	}
+	for _, tt := range tests {
+		r := NewRegistry().(*StandardRegistry)
+		r.SetNamePolicy(tt.policy)
+		tagged := metrics.NewCounter()
+		if err := r.RegisterTagged(NewMetricName("a", "b", "c"), tagged); err != nil {
+			t.Fatal(err)
+		}
+		err := r.Register("a{b=c}", metrics.NewCounter())
+		if tt.want == "" {
+			if _, ok := err.(*InvalidNameError); !ok {
+				t.Errorf("policy %d: Register() error = %v,want an *InvalidNameError", tt.policy, err)
+			}
+		} else if err != nil || r.Get(tt.want) == nil {
+			t.Errorf("policy %d: Register() error = %v,want the metric registered as %s", tt.policy, err, tt.want)
+		}
+		if r.GetTagged(NewMetricName("a", "b", "c")) != tagged {
+			t.Errorf("policy %d: the tagged metric was replaced", tt.policy)
+		}
+	}
+}
+
+func TestTaggedNames(t *testing.T) {
+	r := NewRegistry()
+	name := NewMetricName("http", "method", "GET", "status", "200")
+	reordered := NewMetricName("http", "status", "200", "method", "GET")
+	c := metrics.NewCounter()
+	if err := r.RegisterTagged(name, c, WithUnit("requests")); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		ok   func() bool
+	}{
+		{"key sorts the tags", func() bool { return r.Get("http{method=GET,status=200}") == c }},
+		{"GetTagged in another order", func() bool { return r.GetTagged(reordered) == c }},
+		{"RegisterTagged in another order", func() bool {
+			_, ok := r.RegisterTagged(reordered, metrics.NewCounter()).(*DuplicateMetricError)
+			return ok
+		}},
+		{"GetOrRegisterTagged", func() bool { return r.GetOrRegisterTagged(reordered, metrics.NewCounter) == c }},
+		{"other tags", func() bool { return r.GetTagged(name.Tagged("status", "500")) == nil }},
+		{"untagged base name", func() bool { return r.Get("http") == nil }},
+		{"EachTagged keeps the tags", func() bool {
+			var got MetricName
+			r.EachTagged(func(n MetricName, _ interface{}) { got = n })
+			return got.Name == "http" && len(got.Tags) == 2 && got.Tags[0] == (Tag{Key: "method", Value: "GET"})
+		}},
+		{"Describe", func() bool {
+			d, ok := r.Describe(name.String())
+			return ok && d.Name.String() == name.String() && d.Unit == "requests"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.ok() {
+				t.Errorf("%s doesn't hold for %s", tt.name, name)
+			}
+		})
+	}
+	r.UnregisterTagged(reordered)
+	if r.GetTagged(name) != nil {
+		t.Error("UnregisterTagged in another order didn't unregister the metric")
+	}
+}
+
+//run with -race,the policy is set while metrics are registered
+func TestSetNamePolicyConcurrently(t *testing.T) {
+	r := NewRegistry().(*StandardRegistry)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			r.SetNamePolicy(NamePolicy(i % 3))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			name := "c" + strconv.Itoa(i)
+			r.Register(name, metrics.NewCounter())
+			r.Get(name)
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,223 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/metrics"
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//ParseSnapshotJSON rebuilds the metrics of a MarshalJson payload into a new read-only registry,such as one fetched from another process,
+//see ReadOnly.The metrics are frozen: they keep the parsed values and discard updates,so that reporters can export them again.
+//Timer durations and rates are converted back to nanoseconds and per second rates from the units of the payload.
+func ParseSnapshotJSON(data []byte) (Registry, error) {
+	var payload map[string]jsonValues
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&payload); err != nil {
+		return nil, err
+	}
+	r := NewRegistry()
+	for key, values := range payload {
+		i, err := values.frozen()
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %v", key, err)
+		}
+		if err = r.RegisterTagged(values.name(key), i, values.options()...); err != nil {
+			return nil, err
+		}
+	}
+	return ReadOnly(r), nil
+}
+
+//jsonValues are the values marshalled for one metric
+type jsonValues map[string]interface{}
+
+//name rebuilds the tagged name from the registry key and the tags
+func (v jsonValues) name(key string) MetricName {
+	tags, _ := v["tags"].(map[string]interface{})
+	if len(tags) == 0 {
+		return MetricName{Name: key}
+	}
+	var n MetricName
+	for k, value := range tags {
+		s, _ := value.(string)
+		n = n.Tagged(k, s)
+	}
+	n.Name = strings.TrimSuffix(key, n.String())
+	return n
+}
+
+func (v jsonValues) options() []MetricOption {
+	var opts []MetricOption
+	if s, ok := v["description"].(string); ok {
+		opts = append(opts, WithDescription(s))
+	}
+	if s, ok := v["unit"].(string); ok {
+		opts = append(opts, WithUnit(s))
+	}
+	if b, ok := v["monotonic"].(bool); ok && b {
+		opts = append(opts, WithMonotonic())
+	}
+	return opts
+}
+
+var parsedKinds = []MetricKind{KindCounter, KindGauge, KindGauge64, KindHistogram, KindHistogramFloat64, KindMeter, KindTimer, KindHealthcheck}
+
+//kind is the marshalled one,or guessed from the keys for the payloads without it
+func (v jsonValues) kind() MetricKind {
+	if s, ok := v["kind"].(string); ok {
+		for _, k := range parsedKinds {
+			if k.String() == s {
+				return k
+			}
+		}
+		return KindUnknown
+	}
+	has := func(key string) bool {
+		_, ok := v[key]
+		return ok
+	}
+	switch {
+	case has("duration_unit"):
+		return KindTimer
+	case has("rate_unit"):
+		return KindMeter
+	case has("error"):
+		return KindHealthcheck
+	case has("stddev"):
+		return KindHistogram
+	case has("value"):
+		if n, ok := v["value"].(json.Number); ok && strings.ContainsAny(n.String(), ".eE") {
+			return KindGauge64
+		}
+		return KindGauge
+	case has("count"):
+		return KindCounter
+	}
+	return KindUnknown
+}
+
+func (v jsonValues) frozen() (interface{}, error) {
+	switch kind := v.kind(); kind {
+	case KindCounter:
+		return metrics.NewFrozenCounter(v.int("count")), nil
+	case KindGauge:
+		return metrics.NewFrozenGauge(v.int("value")), nil
+	case KindGauge64:
+		return metrics.NewFrozenGauge64(v.float("value")), nil
+	case KindHistogram:
+		return metrics.NewFrozenHistogram(v.stats(1)), nil
+	case KindHistogramFloat64:
+		return metrics.NewFrozenHistogramFloat64(v.stats(1)), nil
+	case KindMeter:
+		rates, err := v.rates()
+		return metrics.NewFrozenMeter(v.int("count"), rates), err
+	case KindTimer:
+		rates, err := v.rates()
+		if err != nil {
+			return nil, err
+		}
+		unit, err := v.unit("duration_unit", time.Nanosecond)
+		return metrics.NewFrozenTimer(v.stats(float64(unit)), rates), err
+	case KindHealthcheck:
+		if s, ok := v["error"].(string); ok {
+			return metrics.NewFrozenHealthcheck(errors.New(s)), nil
+		}
+		return metrics.NewFrozenHealthcheck(nil), nil
+	}
+	return nil, errors.New("unknown metric kind")
+}
+
+func (v jsonValues) float(key string) float64 {
+	n, _ := v[key].(json.Number)
+	f, _ := n.Float64()
+	return f
+}
+
+func (v jsonValues) int(key string) int64 {
+	n, _ := v[key].(json.Number)
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	return int64(math.Round(v.float(key)))
+}
+
+//unit parses the symbol of the key,the leading '/' of rate units is ignored
+func (v jsonValues) unit(key string, defaultUnit time.Duration) (time.Duration, error) {
+	s, ok := v[key].(string)
+	if !ok {
+		return defaultUnit, nil
+	}
+	unit, ok := output.ParseUnitSymbol(strings.TrimPrefix(s, "/"))
+	if !ok {
+		return 0, fmt.Errorf("unknown %s %q", key, s)
+	}
+	return unit, nil
+}
+
+//rates converted back to per second
+func (v jsonValues) rates() (metrics.FrozenRates, error) {
+	unit, err := v.unit("rate_unit", time.Second)
+	if err != nil {
+		return metrics.FrozenRates{}, err
+	}
+	//the rates were keyed 1m.rate,5m.rate,15m.rate and mean.rate before MarshalJson encoded the frames
+	perSecond := func(key, legacyKey string) float64 {
+		if _, ok := v[key]; !ok {
+			key = legacyKey
+		}
+		return v.float(key) / unit.Seconds()
+	}
+	return metrics.FrozenRates{
+		Rate1:    perSecond("rate1", "1m.rate"),
+		Rate5:    perSecond("rate5", "5m.rate"),
+		Rate15:   perSecond("rate15", "15m.rate"),
+		RateMean: perSecond("rate_mean", "mean.rate"),
+	}, nil
+}
+
+//stats of a histogram or timer,values are multiplied by du
+func (v jsonValues) stats(du float64) metrics.FrozenStats {
+	s := metrics.FrozenStats{
+		Count:       v.int("count"),
+		Sum:         v.float("sum") * du,
+		Min:         v.float("min") * du,
+		Max:         v.float("max") * du,
+		Mean:        v.float("mean") * du,
+		StdDev:      v.float("stddev") * du,
+		Percentiles: make(map[float64]float64),
+	}
+	for key := range v {
+		if p, ok := output.ParsePercentileKey(key); ok {
+			s.Percentiles[p] = v.float(key) * du
+		}
+	}
+	buckets, ok := v["buckets"].(map[string]interface{})
+	if !ok {
+		return s
+	}
+	for le, count := range buckets {
+		b := output.Bucket{UpperBound: math.Inf(1)}
+		if le != "+Inf" {
+			bound, err := strconv.ParseFloat(le, 64)
+			if err != nil {
+				continue
+			}
+			b.UpperBound = bound * du
+		}
+		n, _ := count.(json.Number)
+		b.Count, _ = n.Int64()
+		s.Buckets = append(s.Buckets, b)
+	}
+	sort.Slice(s.Buckets, func(i, j int) bool { return s.Buckets[i].UpperBound < s.Buckets[j].UpperBound })
+	return s
+}
@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+func TestParseSnapshotJSONRoundTrip(t *testing.T) {
+	r := NewRegistry()
+	c := metrics.NewCounter()
+	c.Inc(3)
+	r.Register("requests", c, WithDescription("served requests"), WithMonotonic())
+	r.RegisterTagged(NewMetricName("cpu", "host", "web1"), metrics.NewFrozenGauge(7), WithUnit("cores"))
+	r.RegisterTagged(NewMetricName("load", "host", "web1", "region", "eu"), metrics.NewFrozenGauge64(0.5))
+	h := metrics.NewHistogram(metrics.NewUniformReservoir(10))
+	for i := int64(1); i <= 4; i++ {
+		h.Update(i)
+	}
+	r.Register("sizes", h)
+	timer := metrics.NewTimer()
+	timer.Update(time.Millisecond)
+	r.Register("latency", timer)
+	r.Register("db", metrics.NewFrozenHealthcheck(errors.New("down")))
+	data, err := r.MarshalJson()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseSnapshotJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name MetricName
+		kind MetricKind
+	}{
+		{NewMetricName("requests"), KindCounter},
+		{NewMetricName("cpu", "host", "web1"), KindGauge},
+		{NewMetricName("load", "host", "web1", "region", "eu"), KindGauge64},
+		{NewMetricName("sizes"), KindHistogram},
+		{NewMetricName("latency"), KindTimer},
+		{NewMetricName("db"), KindHealthcheck},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name.String(), func(t *testing.T) {
+			m := parsed.GetTagged(tt.name)
+			if kind := KindOf(m); kind != tt.kind {
+				t.Fatalf("KindOf() = %v,want %v", kind, tt.kind)
+			}
+		})
+	}
+	if d, _ := parsed.Describe("requests"); d.Description != "served requests" || !d.Monotonic {
+		t.Errorf("Describe(requests) = %+v,want the description and monotonic", d)
+	}
+	if o := parsed.Options("cpu{host=web1}"); o.Unit != "cores" {
+		t.Errorf("Options(cpu{host=web1}).Unit = %q,want cores", o.Unit)
+	}
+	again, err := parsed.MarshalJson()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Fatalf("MarshalJson() of the parsed registry = %s\nwant %s", again, data)
+	}
+}
+
+func TestParseSnapshotJSONLegacyRates(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"legacy", `{"m":{"count":3,"1m.rate":60,"5m.rate":120,"15m.rate":180,"mean.rate":240,"rate_unit":"/min"}}`},
+		{"current", `{"m":{"kind":"meter","count":3,"rate1":60,"rate5":120,"rate15":180,"rate_mean":240,"rate_unit":"/min"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseSnapshotJSON([]byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, ok := parsed.Get("m").(mechanism.Meter)
+			if !ok {
+				t.Fatalf("Get(m) = %T,want a meter", parsed.Get("m"))
+			}
+			got := []float64{m.Rate1(), m.Rate5(), m.Rate15(), m.RateMean()}
+			for i, want := range []float64{1, 2, 3, 4} {
+				if got[i] != want {
+					t.Errorf("rates per second = %v,want 1,2,3,4", got)
+					break
+				}
+			}
+			if m.Count() != 3 {
+				t.Errorf("Count() = %d,want 3", m.Count())
+			}
+		})
+	}
+}
+
+func TestParseSnapshotJSONReadOnly(t *testing.T) {
+	parsed, err := ParseSnapshotJSON([]byte(`{"c":{"kind":"counter","count":1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		change func(r Registry) error
+	}{
+		{"register", func(r Registry) error { return r.Register("d", metrics.NewCounter()) }},
+		{"register tagged", func(r Registry) error { return r.RegisterTagged(NewMetricName("d", "k", "v"), metrics.NewCounter()) }},
+		{"register in scope", func(r Registry) error { return r.Scope("s").Register("d", metrics.NewCounter()) }},
+		{"unregister", func(r Registry) error { r.Unregister("c"); return nil }},
+		{"unregister all", func(r Registry) error { r.UnregisterAll(); return nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change(parsed); err != nil {
+				if _, ok := err.(*ReadOnlyError); !ok {
+					t.Fatalf("error = %v,want a *ReadOnlyError", err)
+				}
+			}
+			if names := parsed.Names(); len(names) != 1 || names[0] != "c" {
+				t.Fatalf("Names() = %v,want c only", names)
+			}
+		})
+	}
+	d := metrics.NewCounter()
+	if parsed.GetOrRegister("d", d) != d || parsed.Get("d") != nil {
+		t.Error("GetOrRegister() registered d")
+	}
+}
@@ -0,0 +1,350 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+)
+
+//SnapshotVersion is the version of the format written by Save,Load refuses the newer ones
+const SnapshotVersion = 1
+
+type savedRegistry struct {
+	Version int                    `json:"version"`
+	SavedAt time.Time              `json:"saved_at"`
+	Metrics map[string]savedMetric `json:"metrics"` //keyed by the registry names
+}
+
+type savedMetric struct {
+	Kind  string          `json:"kind"`
+	State json.RawMessage `json:"state"`
+}
+
+//Save writes the state of the metrics implementing mechanism.Persistent,such as counter values,
+//gauge values,meter counts and start times.Reservoir contents are written too if samples is true.
+func Save(r Registry, w io.Writer, samples bool) error {
+	saved := savedRegistry{Version: SnapshotVersion, SavedAt: time.Now(), Metrics: make(map[string]savedMetric)}
+	var err error
+	r.Each(func(name string, i interface{}) {
+		p, ok := i.(mechanism.Persistent)
+		if !ok || err != nil {
+			return
+		}
+		var state []byte
+		if state, err = p.SaveState(samples); err != nil {
+			err = fmt.Errorf("saving %s: %v", name, err)
+			return
+		}
+		saved.Metrics[name] = savedMetric{Kind: KindOf(i).String(), State: state}
+	})
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(saved)
+}
+
+//Load restores the state written by Save into the metrics of the same name and kind.
+//The metrics registered later are restored before they're published,so they can be created lazily after Load,
+//until the returned Restorer has nothing pending or is stopped.Saved metrics of another kind than the registered ones are skipped.
+//The registries other than the standard,scoped and composite ones restore the metrics right after they're published.
+func Load(r Registry, rd io.Reader) (*Restorer, error) {
+	var saved savedRegistry
+	if err := json.NewDecoder(rd).Decode(&saved); err != nil {
+		return nil, err
+	}
+	if saved.Version < 1 || saved.Version > SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d,expecting at most %d", saved.Version, SnapshotVersion)
+	}
+	s := &Restorer{registry: r, pending: saved.Metrics}
+	attachRestorer(r, s, func(name string) (string, bool) { return name, true })
+	var err error
+	r.Each(func(name string, i interface{}) {
+		if restoreErr := s.restore(name, i); err == nil {
+			err = restoreErr
+		}
+		s.restored(name)
+	})
+	s.mutex.Lock()
+	done := len(s.pending) == 0
+	s.mutex.Unlock()
+	if done {
+		s.Stop()
+	}
+	return s, err
+}
+
+//attach the restorer to the standard registries holding the metrics of r,name maps their keys to the names of r
+func attachRestorer(r Registry, s *Restorer, name func(key string) (string, bool)) {
+	switch r := r.(type) {
+	case *StandardRegistry:
+		s.onStop(r.addRestoreHook(&restoreHook{restorer: s, name: name}))
+	case *ScopedRegistry:
+		attachRestorer(r.root, s, func(key string) (string, bool) {
+			if n, ok := name(key); ok {
+				return r.scopedName(n)
+			}
+			return "", false
+		})
+	case *CompositeRegistry:
+		for _, m := range r.mounted() {
+			m := m
+			attachRestorer(m.registry, s, func(key string) (string, bool) {
+				if n, ok := name(key); ok {
+					return m.fullName(n), true
+				}
+				return "", false
+			})
+		}
+	default:
+		r.AddListener(s)
+		s.onStop(func() { r.RemoveListener(s) })
+	}
+}
+
+//restoreHook restores the metrics a standard registry is about to publish
+type restoreHook struct {
+	restorer *Restorer
+	name     func(key string) (string, bool) //name of the metric in the registry given to Load
+}
+
+func (h *restoreHook) restore(key string, i interface{}) {
+	if name, ok := h.name(key); ok {
+		h.restorer.restoreLater(name, i)
+	}
+}
+
+func (h *restoreHook) restored(key string) {
+	if name, ok := h.name(key); ok {
+		h.restorer.restored(name)
+	}
+}
+
+//addRestoreHook makes the registry restore the metrics before they're published,call the returned function to remove it
+func (r *StandardRegistry) addRestoreHook(h *restoreHook) func() {
+	r.listenerMutex.Lock()
+	r.restoreHooks = append(r.restoreHooks[:len(r.restoreHooks):len(r.restoreHooks)], h)
+	r.listenerMutex.Unlock()
+	return func() {
+		r.listenerMutex.Lock()
+		defer r.listenerMutex.Unlock()
+		for i, hook := range r.restoreHooks {
+			if hook == h {
+				r.restoreHooks = append(r.restoreHooks[:i:i], r.restoreHooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+//Restorer restores the saved metrics not registered yet when Load returned,as they get registered.
+//It stops by itself once every saved metric is registered.
+type Restorer struct {
+	RegistryListenerBase
+	registry Registry
+	mutex    sync.Mutex
+	pending  map[string]savedMetric
+	err      error //first error restoring a metric registered after Load
+	detach   []func()
+	stopped  bool
+}
+
+//Pending returns the names of the saved metrics not registered yet,sorted
+func (s *Restorer) Pending() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	names := make([]string, 0, len(s.pending))
+	for name := range s.pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Err returns the first error restoring a metric registered after Load returned,such errors are logged as warnings too
+func (s *Restorer) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+//Stop detaches the restorer from the registry,the metrics registered from now on aren't restored
+func (s *Restorer) Stop() {
+	s.mutex.Lock()
+	detach := s.detach
+	s.detach, s.stopped = nil, true
+	s.mutex.Unlock()
+	for _, d := range detach {
+		d()
+	}
+}
+
+//onStop calls detach when the restorer stops,right away if it's already stopped
+func (s *Restorer) onStop(detach func()) {
+	s.mutex.Lock()
+	if !s.stopped {
+		s.detach = append(s.detach, detach)
+		detach = nil
+	}
+	s.mutex.Unlock()
+	if detach != nil {
+		detach()
+	}
+}
+
+//restore the saved state into the metric,it stays pending until restored is called
+func (s *Restorer) restore(name string, i interface{}) error {
+	s.mutex.Lock()
+	saved, ok := s.pending[name]
+	s.mutex.Unlock()
+	p, persistent := i.(mechanism.Persistent)
+	if !ok || !persistent || saved.Kind != KindOf(i).String() {
+		return nil
+	}
+	if err := p.RestoreState(saved.State); err != nil {
+		return fmt.Errorf("restoring %s: %v", name, err)
+	}
+	return nil
+}
+
+//restore a metric registered after Load,logging the error and keeping the first one for Err
+func (s *Restorer) restoreLater(name string, i interface{}) {
+	err := s.restore(name, i)
+	if err == nil {
+		return
+	}
+	loggerOf(s.registry, name).Warn("metric state not restored", "name", name, "error", err)
+	s.mutex.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mutex.Unlock()
+}
+
+//restored drops the saved state of the published metric,the restorer stops once nothing is pending
+func (s *Restorer) restored(name string) {
+	s.mutex.Lock()
+	_, ok := s.pending[name]
+	delete(s.pending, name)
+	done := ok && len(s.pending) == 0
+	s.mutex.Unlock()
+	if done {
+		s.Stop()
+	}
+}
+
+//the registries which can't restore the metrics before publishing them notify the restorer once they're added
+
+func (s *Restorer) restoreAdded(name string, i interface{}) {
+	s.restoreLater(name, i)
+	s.restored(name)
+}
+
+func (s *Restorer) OnCounterAdded(name string, counter mechanism.Counter) {
+	s.restoreAdded(name, counter)
+}
+func (s *Restorer) OnGaugeAdded(name string, gauge mechanism.Gauge) {
+	s.restoreAdded(name, gauge)
+}
+func (s *Restorer) OnGauge64Added(name string, gauge mechanism.Gauge64) {
+	s.restoreAdded(name, gauge)
+}
+func (s *Restorer) OnHistogramAdded(name string, histogram mechanism.Histogram) {
+	s.restoreAdded(name, histogram)
+}
+func (s *Restorer) OnHistogramFloat64Added(name string, histogram mechanism.HistogramFloat64) {
+	s.restoreAdded(name, histogram)
+}
+func (s *Restorer) OnMeterAdded(name string, meter mechanism.Meter) {
+	s.restoreAdded(name, meter)
+}
+func (s *Restorer) OnTimerAdded(name string, timer mechanism.Timer) {
+	s.restoreAdded(name, timer)
+}
+
+//FileSnapshotter saves a registry to a file on an interval and when stopped,see StartFileSnapshots
+type FileSnapshotter struct {
+	registry Registry
+	path     string
+	samples  bool
+	restorer *Restorer //of the loaded file,nil if there was none
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+//StartFileSnapshots loads the file into the registry if it exists,then saves the registry to it every interval,
+//never if interval isn't positive.Call Stop on shutdown to save the last state.
+func StartFileSnapshots(r Registry, path string, interval time.Duration, samples bool) (*FileSnapshotter, error) {
+	s := &FileSnapshotter{registry: r, path: path, samples: samples, stop: make(chan struct{}), done: make(chan struct{})}
+	f, err := os.Open(path)
+	if err == nil {
+		s.restorer, err = Load(r, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	go s.run(interval)
+	return s, nil
+}
+
+func (s *FileSnapshotter) run(interval time.Duration) {
+	defer close(s.done)
+	if interval <= 0 {
+		<-s.stop
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.SaveNow()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+//SaveNow writes the registry to a temporary file renamed over the snapshot,so that a crash never leaves it half written
+func (s *FileSnapshotter) SaveNow() error {
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if err = Save(s.registry, tmp, s.samples); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+//Stop ends the interval saves and the restoration of the loaded metrics,and saves the last state
+func (s *FileSnapshotter) Stop() error {
+	s.once.Do(func() {
+		close(s.stop)
+		if s.restorer != nil {
+			s.restorer.Stop()
+		}
+	})
+	<-s.done
+	return s.SaveNow()
+}
@@ -0,0 +1,122 @@
+package registry
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+//a snapshot of the counters c=5 and d=7
+func savedCounters(t *testing.T) []byte {
+	t.Helper()
+	r := NewRegistry()
+	c, d := metrics.NewCounter(), metrics.NewCounter()
+	c.Inc(5)
+	d.Inc(7)
+	r.Register("c", c)
+	r.Register("d", d)
+	var buf bytes.Buffer
+	if err := Save(r, &buf, false); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+//incrementing increments the counters d as soon as they're published
+type incrementing struct {
+	RegistryListenerBase
+}
+
+func (incrementing) OnCounterAdded(name string, c mechanism.Counter) {
+	if strings.HasSuffix(name, "d") {
+		c.Inc(1)
+	}
+}
+
+func TestLoadRestoresBeforePublishing(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope func(r Registry) Registry
+	}{
+		{"standard", func(r Registry) Registry { return r }},
+		{"scope", func(r Registry) Registry { return r.Scope("api") }},
+		{"composite", func(r Registry) Registry {
+			c := NewCompositeRegistry()
+			c.Mount("", r)
+			return c
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := NewRegistry()
+			root.AddListener(incrementing{})
+			r := tt.scope(root)
+			r.Register("c", metrics.NewCounter())
+			restorer, err := Load(r, bytes.NewReader(savedCounters(t)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count := r.Get("c").(mechanism.Counter).Count(); count != 5 {
+				t.Fatalf("c = %d, want 5", count)
+			}
+			if pending := restorer.Pending(); len(pending) != 1 || pending[0] != "d" {
+				t.Fatalf("Pending() = %v, want d", pending)
+			}
+			d := metrics.NewCounter()
+			r.Register("d", d)
+			if count := d.Count(); count != 8 {
+				t.Fatalf("d = %d, want 7 restored and incremented once published", count)
+			}
+			if pending := restorer.Pending(); len(pending) != 0 {
+				t.Fatalf("Pending() = %v, want nothing", pending)
+			}
+			if hooks := len(root.(*StandardRegistry).restoreHooks); hooks != 0 {
+				t.Fatalf("%d restore hooks left once nothing is pending", hooks)
+			}
+		})
+	}
+}
+
+func TestRestorerStop(t *testing.T) {
+	r := NewRegistry()
+	restorer, err := Load(r, bytes.NewReader(savedCounters(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restorer.Stop()
+	if hooks := len(r.(*StandardRegistry).restoreHooks); hooks != 0 {
+		t.Fatalf("%d restore hooks left after Stop", hooks)
+	}
+	c := metrics.NewCounter()
+	r.Register("c", c)
+	if count := c.Count(); count != 0 {
+		t.Fatalf("c = %d registered after Stop, want 0", count)
+	}
+}
+
+func TestRestorerErrors(t *testing.T) {
+	saved := `{"version":1,"metrics":{"c":{"kind":"` + KindCounter.String() + `","state":"broken"}}}`
+	r := NewRegistry()
+	logger := newRecordingLogger()
+	r.(*StandardRegistry).SetLogger(logger)
+	restorer, err := Load(r, strings.NewReader(saved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Register("c", metrics.NewCounter())
+	if restorer.Err() == nil {
+		t.Fatal("Err() = nil after restoring a broken state")
+	}
+	if warnings := logger.logged("warn"); len(warnings) != 1 || !strings.Contains(warnings[0], "metric state not restored") {
+		t.Fatalf("warnings = %q, want the restore error", warnings)
+	}
+
+	r = NewRegistry()
+	r.Register("c", metrics.NewCounter())
+	if _, err := Load(r, strings.NewReader(saved)); err == nil {
+		t.Fatal("Load() = nil restoring a broken state into a registered metric")
+	}
+}
@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+//a registry of n counters spread over 100 prefixes,such as svc42.requests.1042
+func populated(b *testing.B, n int) Registry {
+	b.Helper()
+	r := NewRegistry()
+	for i := 0; i < n; i++ {
+		if err := r.Register(fmt.Sprintf("svc%d.requests.%d", i%100, i), metrics.NewCounter()); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return r
+}
+
+func BenchmarkNames100k(b *testing.B) {
+	r := populated(b, 100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if names := r.Names(); len(names) != 100000 {
+			b.Fatalf("%d names, want 100000", len(names))
+		}
+	}
+}
+
+func BenchmarkFind100k(b *testing.B) {
+	r := populated(b, 100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if found := r.Find("svc42.*"); len(found) != 1000 {
+			b.Fatalf("found %d metrics, want 1000", len(found))
+		}
+	}
+}
+
+func BenchmarkEachMatching100k(b *testing.B) {
+	r := populated(b, 100000)
+	filters := []struct {
+		name   string
+		filter MetricFilter
+		want   int
+	}{
+		{"prefix", NamePrefixFilter("svc42."), 1000},
+		{"glob", GlobFilter("svc4?.*"), 10000},
+		{"kind", KindFilter(KindCounter), 100000},
+	}
+	for _, f := range filters {
+		b.Run(f.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				matched := 0
+				r.EachMatching(f.filter, func(MetricName, interface{}) { matched++ })
+				if matched != f.want {
+					b.Fatalf("%d matching metrics, want %d", matched, f.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package registry
+
+//readOnlyRegistry serves the metrics of a registry but rejects the registrations and ignores the unregistrations
+type readOnlyRegistry struct {
+	Registry
+}
+
+//ReadOnly returns a view of the registry which can't register nor unregister metrics,registrations fail with a *ReadOnlyError.
+//The metrics themselves are still updatable
+func ReadOnly(r Registry) Registry {
+	if _, ok := r.(*readOnlyRegistry); ok {
+		return r
+	}
+	return &readOnlyRegistry{Registry: r}
+}
+
+//GetOrRegister returns the registered metric,or the given one unregistered if there is none
+func (r *readOnlyRegistry) GetOrRegister(name string, i interface{}, _ ...MetricOption) interface{} {
+	if m := r.Get(name); m != nil {
+		return m
+	}
+	return i
+}
+
+func (r *readOnlyRegistry) Register(name string, _ interface{}, _ ...MetricOption) error {
+	return &ReadOnlyError{Name: name}
+}
+
+//GetOrRegisterTagged returns the registered metric,or the given one unregistered if there is none
+func (r *readOnlyRegistry) GetOrRegisterTagged(name MetricName, i interface{}, _ ...MetricOption) interface{} {
+	if m := r.GetTagged(name); m != nil {
+		return m
+	}
+	return i
+}
+
+func (r *readOnlyRegistry) RegisterTagged(name MetricName, _ interface{}, _ ...MetricOption) error {
+	return &ReadOnlyError{Name: name.String()}
+}
+
+func (r *readOnlyRegistry) UnregisterTagged(MetricName) {}
+func (r *readOnlyRegistry) Unregister(string)           {}
+func (r *readOnlyRegistry) UnregisterAll()              {}
+func (r *readOnlyRegistry) UnregisterScope(string)      {}
+
+//Scope returns a read-only view of the scope
+func (r *readOnlyRegistry) Scope(name string) Registry {
+	return ReadOnly(r.Registry.Scope(name))
+}
@@ -3,84 +3,438 @@ package registry
 import (
 	"encoding/json"
 	"reflect"
-
-	"errors"
-
-	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/carbin-gun/awesome-metrics/mechanism"
-	"github.com/fanliao/go-concurrentMap"
+	"github.com/carbin-gun/awesome-metrics/output"
 )
 
 type Registry interface {
 	Each(func(string, interface{}))
 	Get(string) interface{}
-	GetOrRegister(string, interface{}) interface{}
-	Register(string, interface{}) error
+	GetOrRegister(string, interface{}, ...MetricOption) interface{}
+	Register(string, interface{}, ...MetricOption) error
+	Options(string) MetricOptions
+	Describe(string) (MetricDescription, bool)
+	EachTagged(func(MetricName, interface{}))
+	GetTagged(MetricName) interface{}
+	GetOrRegisterTagged(MetricName, interface{}, ...MetricOption) interface{}
+	RegisterTagged(MetricName, interface{}, ...MetricOption) error
+	UnregisterTagged(MetricName)
 	Unregister(string)
 	UnregisterAll()
 	Prefix() string
+	Scope(string) Registry
+	UnregisterScope(string)
+	AddListener(RegistryListener)
+	RemoveListener(RegistryListener)
 	MarshalJson() ([]byte, error)
+	MarshalJsonWithOptions(output.Options) ([]byte, error)
+	Names() []string
+	Find(string) map[string]interface{}
+	EachMatching(MetricFilter, func(MetricName, interface{}))
+}
+
+//MetricOptions are the per metric settings given at registration
+type MetricOptions struct {
+	Percentiles []float64 //percentiles to report,overriding the reporter ones
+	Description string    //what the metric measures
+	Unit        string    //unit of the values,such as bytes or requests
+	Monotonic   bool      //the count only goes up,such as a counter never decremented
+}
+
+type MetricOption func(*MetricOptions)
+
+//WithPercentiles sets the percentiles reported for the metric,such as 0.5 and 0.999
+func WithPercentiles(percentiles ...float64) MetricOption {
+	return func(o *MetricOptions) {
+		o.Percentiles = percentiles
+	}
+}
+
+//WithDescription describes what the metric measures
+func WithDescription(description string) MetricOption {
+	return func(o *MetricOptions) {
+		o.Description = description
+	}
+}
+
+//WithUnit sets the unit of the values of the metric,such as bytes
+func WithUnit(unit string) MetricOption {
+	return func(o *MetricOptions) {
+		o.Unit = unit
+	}
+}
+
+//WithMonotonic declares that the count of the metric only goes up,so that it can be exposed as a counter
+func WithMonotonic() MetricOption {
+	return func(o *MetricOptions) {
+		o.Monotonic = true
+	}
+}
+
+//MetricDescription is the metadata of a registered metric
+type MetricDescription struct {
+	Name        MetricName
+	Kind        MetricKind
+	Description string
+	Unit        string
+	Monotonic   bool //always true for meters
 }
 
 type StandardRegistry struct {
+	version         uint64 //incremented on every registration and unregistration,first for its 64-bit alignment
 	universalPrefix string
-	metrics         *concurrent.ConcurrentMap
+	metrics         sync.Map     //key -> *metricEntry,read without locking
+	index           atomic.Value //*metricIndex,nil until the first iteration
+	indexMutex      sync.Mutex   //held while rebuilding the index
+	listenerMutex   sync.RWMutex //held for reading while storing,so that added listeners miss nothing,never while notifying
+	listeners       []RegistryListener
+	restoreHooks    []*restoreHook //restore the metrics loaded by Load before they're published
+	limiter         *limiter       //nil if the registry has no limits
+	namePolicy      int32          //NamePolicy,atomic as SetNamePolicy may run while metrics are registered
+	logger          Logger         //nil for silent
+}
+
+//Logger receives the registration events,see output.Logger
+type Logger = output.Logger
+
+//metricEntry is what the registry holds per key,it isn't modified once stored
+type metricEntry struct {
+	key     string
+	name    MetricName //tagged name,just the key for the metrics registered by a plain string
+	metric  interface{}
+	options MetricOptions
+}
+
+//metricIndex is the sorted list of the entries at a version of the registry,shared by the iterations until the next change
+type metricIndex struct {
+	version uint64
+	entries []*metricEntry
 }
 
 //Registry creation with specifying the universal prefix of all the metrics-keys
 func NewPrefixRegistry(prefix string) Registry {
-	return &StandardRegistry{universalPrefix: prefix, metrics: concurrent.NewConcurrentMap()}
+	return &StandardRegistry{universalPrefix: prefix}
 }
 
 // Create a new registry.
 func NewRegistry() Registry {
-	return &StandardRegistry{metrics: concurrent.NewConcurrentMap()}
+	return &StandardRegistry{}
 }
 
 // Call the given function for each registered metric.
 func (r *StandardRegistry) Each(f func(string, interface{})) {
-	for name, i := range r.registered() {
-		f(name, i)
+	r.each(f)
+}
+
+//each calls f for every registered metric in the order of the names,iterating the shared index without allocating
+func (r *StandardRegistry) each(f func(string, interface{})) {
+	for _, e := range r.sorted() {
+		f(e.key, e.metric)
+	}
+}
+
+//sorted returns the entries sorted by key,the index is rebuilt only if the registry changed since the last call.
+//A change during the rebuild makes the index stale at once,so that the next call rebuilds it again.
+func (r *StandardRegistry) sorted() []*metricEntry {
+	if index, _ := r.index.Load().(*metricIndex); index != nil && index.version == atomic.LoadUint64(&r.version) {
+		return index.entries
+	}
+	r.indexMutex.Lock()
+	defer r.indexMutex.Unlock()
+	version := atomic.LoadUint64(&r.version)
+	if index, _ := r.index.Load().(*metricIndex); index != nil && index.version == version {
+		return index.entries
+	}
+	var entries []*metricEntry
+	r.metrics.Range(func(_, e interface{}) bool {
+		entries = append(entries, e.(*metricEntry))
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	r.index.Store(&metricIndex{version: version, entries: entries})
+	return entries
+}
+
+//entry returns the entry of the key,nil if none is registered
+func (r *StandardRegistry) entry(key string) *metricEntry {
+	if e, ok := r.metrics.Load(key); ok {
+		return e.(*metricEntry)
+	}
+	return nil
+}
+
+// Names returns the names of the registered metrics,sorted.
+func (r *StandardRegistry) Names() []string {
+	entries := r.sorted()
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.key
+	}
+	return names
+}
+
+// Find returns the metrics whose base name matches the shell pattern,such as db.*,keyed by their names.
+func (r *StandardRegistry) Find(pattern string) map[string]interface{} {
+	return find(r, pattern)
+}
+
+// EachMatching calls the function for each metric matched by the filter.
+func (r *StandardRegistry) EachMatching(filter MetricFilter, f func(MetricName, interface{})) {
+	EachFiltered(r, filter, f)
+}
+
+//lookup returns the entry of the name,with the NameNormalize policy the normalized name is looked up
+//if the name isn't registered as given.Every lookup by a plain name goes through it
+func (r *StandardRegistry) lookup(name string) *metricEntry {
+	e := r.entry(name)
+	if e == nil && r.policy() == NameNormalize {
+		e = r.entry(NormalizeName(name))
 	}
+	return e
 }
 
 // Get the metric by the given name or nil if none is registered.
+// With the NameNormalize policy,the normalized name is looked up if the name isn't registered as given.
 func (r *StandardRegistry) Get(name string) interface{} {
-	val, _ := r.metrics.Get(name)
-	return val
+	if e := r.lookup(name); e != nil {
+		return e.metric
+	}
+	return nil
+}
+
+// SetLogger sets the logger of the registration events,registrations are silent by default.
+// Conflicts and rejections are logged as warnings,registrations and unregistrations as debug events.
+func (r *StandardRegistry) SetLogger(l Logger) {
+	r.logger = l
+}
+
+func (r *StandardRegistry) log() Logger {
+	if r.logger == nil {
+		return output.NopLogger
+	}
+	return r.logger
+}
+
+// SetNamePolicy sets what is done with the invalid names from now on,it should be called before registering metrics
+// as the names registered before aren't changed.Names are accepted as given by default.
+func (r *StandardRegistry) SetNamePolicy(policy NamePolicy) {
+	atomic.StoreInt32(&r.namePolicy, int32(policy))
+}
+
+//policy is the NamePolicy set by SetNamePolicy
+func (r *StandardRegistry) policy() NamePolicy {
+	return NamePolicy(atomic.LoadInt32(&r.namePolicy))
 }
 
 // put if absent.
-func (r *StandardRegistry) GetOrRegister(name string, i interface{}) interface{} {
+func (r *StandardRegistry) GetOrRegister(name string, i interface{}, opts ...MetricOption) interface{} {
+	if r.policy() != NameAccept {
+		return r.GetOrRegisterTagged(MetricName{Name: name}, i, opts...)
+	}
 	val := r.Get(name)
 	if val != nil {
-		fmt.Println("val existed for metric-name:", name)
 		return val
 	}
 	if v := reflect.ValueOf(i); v.Kind() == reflect.Func {
 		i = v.Call(nil)[0].Interface()
 	}
-	r.register(name, i)
+	if err := r.register(MetricName{Name: name}, i, opts); err != nil {
+		//lost the race to another registration of the name,return the winner
+		if _, ok := err.(*DuplicateMetricError); ok {
+			if val := r.Get(name); val != nil {
+				return val
+			}
+		}
+		return fallback(err, i)
+	}
 	return i
 }
 
-// Register the given metric under the given name.  Returns a DuplicateMetric
-// if a metric by the given name is already registered.
-func (r *StandardRegistry) Register(name string, i interface{}) error {
-	return r.register(name, i)
+//the metric GetOrRegister returns when the registration failed
+func fallback(err error, i interface{}) interface{} {
+	if limitErr, ok := err.(*LimitExceededError); ok && limitErr.Fallback != nil {
+		return limitErr.Fallback
+	}
+	return i
+}
+
+// Register the given metric under the given name.  Returns a *DuplicateMetricError
+// if a metric by the given name is already registered,and an *UnsupportedMetricError
+// if the value isn't a supported metric,and a *LimitExceededError if the registry is full.
+// Whatever the name policy,a name holding '{' is rejected with an *InvalidNameError,as it's the start of the tags of a key.
+func (r *StandardRegistry) Register(name string, i interface{}, opts ...MetricOption) error {
+	if r.policy() != NameAccept {
+		return r.RegisterTagged(MetricName{Name: name}, i, opts...)
+	}
+	return r.register(MetricName{Name: name}, i, opts)
+}
+
+// Options returns the settings the metric was registered with,zero value if none.
+// The name is looked up like Get does.
+func (r *StandardRegistry) Options(name string) MetricOptions {
+	if e := r.lookup(name); e != nil {
+		return e.options
+	}
+	return MetricOptions{}
+}
+
+// Describe returns the metadata of the metric registered under the name,false if none is registered.
+// The name is looked up like Get does.
+func (r *StandardRegistry) Describe(name string) (MetricDescription, bool) {
+	e := r.lookup(name)
+	if e == nil {
+		return MetricDescription{}, false
+	}
+	return describe(e.name, e.metric, e.options), true
+}
+
+func describe(name MetricName, i interface{}, o MetricOptions) MetricDescription {
+	kind := KindOf(i)
+	return MetricDescription{
+		Name:        name,
+		Kind:        kind,
+		Description: o.Description,
+		Unit:        o.Unit,
+		Monotonic:   o.Monotonic || kind == KindMeter,
+	}
 }
 
 // Unregister the metric with the given name.
 func (r *StandardRegistry) Unregister(name string) {
-	r.metrics.Remove(name)
+	if e := r.lookup(name); e != nil {
+		name = e.key
+	}
+	r.remove(name, nil)
+}
+
+//remove the entry of the key and notify the listeners,only if matches accepts it when matches isn't nil.
+//It returns whether an entry was removed
+func (r *StandardRegistry) remove(key string, matches func(*metricEntry) bool) bool {
+	r.listenerMutex.RLock()
+	var previous *metricEntry
+	if matches == nil {
+		if e, ok := r.metrics.LoadAndDelete(key); ok {
+			previous = e.(*metricEntry)
+		}
+	} else if e := r.entry(key); e != nil && matches(e) && r.metrics.CompareAndDelete(key, e) {
+		previous = e
+	}
+	if previous == nil {
+		r.listenerMutex.RUnlock()
+		return false
+	}
+	atomic.AddUint64(&r.version, 1)
+	metric := previous.metric
+	if r.limiter != nil {
+		r.limiter.release(key, metric)
+	}
+	listeners := r.listeners
+	r.listenerMutex.RUnlock()
+	r.log().Debug("metric unregistered", "name", key)
+	for _, l := range listeners {
+		notifyRemoved(l, key, metric)
+	}
+	return true
+}
+
+// AddListener adds a listener notified of every metric added or removed
+// from now on,the metrics already registered are replayed to it as added.
+// Listeners are called without any lock held,so they may register and unregister metrics,
+// the events of metrics changed concurrently may arrive in any order.
+func (r *StandardRegistry) AddListener(l RegistryListener) {
+	r.listenerMutex.Lock()
+	r.listeners = append(r.listeners[:len(r.listeners):len(r.listeners)], l)
+	registered := r.sorted()
+	r.listenerMutex.Unlock()
+	for _, e := range registered {
+		notifyAdded(l, e.key, e.metric)
+	}
+}
+
+// RemoveListener removes a listener added by AddListener,listeners are compared with ==,
+// so they are usually pointers.
+func (r *StandardRegistry) RemoveListener(l RegistryListener) {
+	r.listenerMutex.Lock()
+	defer r.listenerMutex.Unlock()
+	for i, listener := range r.listeners {
+		if listener == l {
+			r.listeners = append(r.listeners[:i:i], r.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+// Call the given function for each registered metric with its tagged name,
+// metrics registered by a plain string have no tags.
+func (r *StandardRegistry) EachTagged(f func(MetricName, interface{})) {
+	for _, e := range r.sorted() {
+		f(e.name, e.metric)
+	}
+}
+
+// Get the metric by the given tagged name or nil if none is registered.
+func (r *StandardRegistry) GetTagged(name MetricName) interface{} {
+	if r.policy() == NameNormalize {
+		name, _ = applyNamePolicy(NameNormalize, name)
+	}
+	if e := r.entry(name.String()); e != nil {
+		return e.metric
+	}
+	return nil
+}
+
+// put if absent,keyed by the tagged name.
+func (r *StandardRegistry) GetOrRegisterTagged(name MetricName, i interface{}, opts ...MetricOption) interface{} {
+	name, policyErr := applyNamePolicy(r.policy(), name)
+	key := name.String()
+	if policyErr == nil {
+		if val := r.Get(key); val != nil {
+			return val
+		}
+	}
+	if v := reflect.ValueOf(i); v.Kind() == reflect.Func {
+		i = v.Call(nil)[0].Interface()
+	}
+	if policyErr != nil {
+		return i
+	}
+	if err := r.RegisterTagged(name, i, opts...); err != nil {
+		if val := r.Get(key); val != nil {
+			return val
+		}
+		return fallback(err, i)
+	}
+	return i
+}
+
+// Register the given metric under the given tagged name.
+func (r *StandardRegistry) RegisterTagged(name MetricName, i interface{}, opts ...MetricOption) error {
+	name, err := applyNamePolicy(r.policy(), name)
+	if err != nil {
+		r.log().Warn("metric registration rejected", "name", name.String(), "error", err)
+		return err
+	}
+	return r.register(name, i, opts)
+}
+
+// Unregister the metric with the given tagged name.
+func (r *StandardRegistry) UnregisterTagged(name MetricName) {
+	if r.policy() == NameNormalize {
+		name, _ = applyNamePolicy(NameNormalize, name)
+	}
+	r.Unregister(name.String())
 }
 
 // Unregister all metrics.  (Mostly for testing.)
 func (r *StandardRegistry) UnregisterAll() {
-	all := r.metrics.ToSlice()
-	for name, _ := range all {
-		r.metrics.Remove(name)
+	for _, e := range r.sorted() {
+		r.Unregister(e.key)
 	}
 }
 
@@ -89,75 +443,98 @@ func (r *StandardRegistry) Prefix() string {
 	return r.universalPrefix
 }
 
-func (r *StandardRegistry) register(name string, i interface{}) error {
-	if val, err := r.metrics.Get(name); err != nil || val != nil {
-		return errors.New("register error for name:" + name)
+// Scope returns a child registry sharing the storage of this one,whose metric
+// names are transparently prefixed by the scope name and a point.
+func (r *StandardRegistry) Scope(name string) Registry {
+	return &ScopedRegistry{root: r, scope: name}
+}
+
+// Unregister all the metrics of the scope,including the ones of its nested scopes.
+func (r *StandardRegistry) UnregisterScope(name string) {
+	r.Scope(name).UnregisterAll()
+}
+
+//register stores the metric,logging the outcome
+func (r *StandardRegistry) register(name MetricName, i interface{}, opts []MetricOption) error {
+	if err := r.store(name, i, opts); err != nil {
+		r.log().Warn("metric registration failed", "name", name.String(), "kind", KindOf(i).String(), "error", err)
+		return err
+	}
+	r.log().Debug("metric registered", "name", name.String(), "kind", KindOf(i).String())
+	return nil
+}
+
+func (r *StandardRegistry) store(name MetricName, i interface{}, opts []MetricOption) error {
+	key := name.String()
+	if KindOf(i) == KindUnknown {
+		return &UnsupportedMetricError{Name: key, Value: i}
+	}
+	if strings.ContainsRune(name.Name, '{') {
+		//a{b=c} would be the key of the name a tagged b=c
+		return &InvalidNameError{Name: key, Reason: "'{' starts the tags of a key,register the tags with RegisterTagged"}
 	}
-	switch i.(type) {
-	case mechanism.Counter, mechanism.Gauge, mechanism.Gauge64, mechanism.Histogram, mechanism.Meter, mechanism.Timer:
-		fmt.Println("putIfAbsent metric,name:", name)
-		r.metrics.PutIfAbsent(name, i)
+	listeners, hooks, err := r.insert(key, name, i, opts)
+	if err != nil {
+		return err
+	}
+	for _, h := range hooks {
+		h.restored(key)
+	}
+	for _, l := range listeners {
+		notifyAdded(l, key, i)
 	}
 	return nil
 }
 
-func (r *StandardRegistry) registered() map[string]interface{} {
-	metrics := make(map[string]interface{}, r.metrics.Size())
-	metricSlices := r.metrics.ToSlice()
-	for _, entry := range metricSlices {
-		keyString := entry.Key().(string)
-		fmt.Println("registered metrics:", metricSlices)
-		metrics[keyString] = entry.Value()
+//insert the entry of the metric,restored first by the restore hooks,
+//returning the hooks and the listeners to notify once the lock is released
+func (r *StandardRegistry) insert(key string, name MetricName, i interface{}, opts []MetricOption) ([]RegistryListener, []*restoreHook, error) {
+	r.listenerMutex.RLock()
+	defer r.listenerMutex.RUnlock()
+	if r.entry(key) != nil {
+		return nil, nil, &DuplicateMetricError{Name: key}
+	}
+	if r.limiter != nil {
+		if err := r.limiter.reserve(key, i); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, h := range r.restoreHooks {
+		h.restore(key, i)
 	}
-	return metrics
+	e := &metricEntry{key: key, name: name, metric: i}
+	for _, opt := range opts {
+		opt(&e.options)
+	}
+	if _, loaded := r.metrics.LoadOrStore(key, e); loaded {
+		if r.limiter != nil {
+			r.limiter.release(key, i)
+		}
+		return nil, nil, &DuplicateMetricError{Name: key}
+	}
+	atomic.AddUint64(&r.version, 1)
+	return r.listeners, r.restoreHooks, nil
 }
-func (r *StandardRegistry) MarshalJson() ([]byte, error) {
-	data := make(map[string]map[string]interface{})
+
+//RunHealthchecks runs the check of every healthcheck of the registry,reporters report the result of the last check
+func RunHealthchecks(r Registry) {
 	r.Each(func(name string, i interface{}) {
-		values := make(map[string]interface{})
-		switch metric := i.(type) {
-		case mechanism.Counter:
-			values["count"] = metric.Count()
-		case mechanism.Gauge:
-			values["value"] = metric.Value()
-		case mechanism.Gauge64:
-			values["value"] = metric.Value()
-		case mechanism.Histogram:
-			h := metric.Snapshot()
-			values["count"] = metric.Count()
-			values["min"] = h.Min()
-			values["max"] = h.Max()
-			values["mean"] = h.Mean()
-			values["stddev"] = h.StdDev()
-			values["median"] = h.Median()
-			values["75%"] = h.Get75thPercentile()
-			values["95%"] = h.Get95thPercentile()
-			values["99%"] = h.Get99thPercentile()
-			values["99.9%"] = h.Get999thPercentile()
-		case mechanism.Meter:
-			values["count"] = metric.Count()
-			values["1m.rate"] = metric.Rate1()
-			values["5m.rate"] = metric.Rate5()
-			values["15m.rate"] = metric.Rate15()
-			values["mean.rate"] = metric.RateMean()
-		case mechanism.Timer:
-			t := metric.Snapshot()
-			values["count"] = metric.Count()
-			values["min"] = t.Min()
-			values["max"] = t.Max()
-			values["mean"] = t.Mean()
-			values["stddev"] = t.StdDev()
-			values["median"] = t.Median()
-			values["75%"] = t.Get75thPercentile()
-			values["95%"] = t.Get95thPercentile()
-			values["99%"] = t.Get99thPercentile()
-			values["99.9%"] = t.Get999thPercentile()
-			values["1m.rate"] = metric.Rate1()
-			values["5m.rate"] = metric.Rate5()
-			values["15m.rate"] = metric.Rate15()
-			values["mean.rate"] = metric.RateMean()
+		if h, ok := i.(mechanism.Healthcheck); ok {
+			h.Check()
 		}
-		data[name] = values
 	})
-	return json.Marshal(data)
+}
+
+func (r *StandardRegistry) MarshalJson() ([]byte, error) {
+	return r.MarshalJsonWithOptions(output.Options{})
+}
+
+//MarshalJsonWithOptions is just like MarshalJson,but converts timer durations and rates to the units of the options
+func (r *StandardRegistry) MarshalJsonWithOptions(o output.Options) ([]byte, error) {
+	return marshalJson(r, o)
+}
+
+//marshal the frame of any registry,keyed by the names prefixed by the prefix of the registry like in the reporters
+func marshalJson(r Registry, o output.Options) ([]byte, error) {
+	return json.Marshal(NewFrame(r, o, time.Now()))
 }
@@ -0,0 +1,156 @@
+package registry
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+//every concurrent caller gets the registered instance,even the ones losing the registration race
+func TestGetOrRegisterConcurrent(t *testing.T) {
+	slowCounter := func() mechanism.Counter {
+		time.Sleep(time.Millisecond)
+		return metrics.NewCounter()
+	}
+	tests := []struct {
+		name       string
+		getOrPut   func(r Registry) interface{}
+		registered func(r Registry) interface{}
+	}{
+		{"plain", func(r Registry) interface{} {
+			return r.GetOrRegister("c", slowCounter)
+		}, func(r Registry) interface{} { return r.Get("c") }},
+		{"tagged", func(r Registry) interface{} {
+			return r.GetOrRegisterTagged(MetricName{Name: "c"}.Tagged("k", "v"), slowCounter)
+		}, func(r Registry) interface{} { return r.GetTagged(MetricName{Name: "c"}.Tagged("k", "v")) }},
+		{"scope", func(r Registry) interface{} {
+			return r.Scope("s").GetOrRegister("c", slowCounter)
+		}, func(r Registry) interface{} { return r.Get("s.c") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			got := make([]interface{}, 16)
+			var wg sync.WaitGroup
+			for i := range got {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					got[i] = tt.getOrPut(r)
+				}(i)
+			}
+			wg.Wait()
+			want := tt.registered(r)
+			if want == nil {
+				t.Fatal("no metric registered")
+			}
+			for i, m := range got {
+				if m != want {
+					t.Errorf("caller %d got %p,want the registered %p", i, m, want)
+				}
+			}
+		})
+	}
+}
+
+var registrySizes = []int{10000, 100000}
+
+func BenchmarkRegistryGet(b *testing.B) {
+	for _, n := range registrySizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			r := populated(b, n)
+			name := fmt.Sprintf("svc%d.requests.%d", (n/2)%100, n/2)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if r.Get(name) == nil {
+					b.Fatal("missing metric")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRegistryGetParallel(b *testing.B) {
+	for _, n := range registrySizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			r := populated(b, n)
+			names := r.Names()
+			var next uint64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if r.Get(names[atomic.AddUint64(&next, 1)%uint64(len(names))]) == nil {
+						b.Fatal("missing metric")
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkRegistryGetOrRegisterExisting(b *testing.B) {
+	for _, n := range registrySizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			r := populated(b, n)
+			name := fmt.Sprintf("svc%d.requests.%d", (n/2)%100, n/2)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.GetOrRegister(name, metrics.NewCounter)
+			}
+		})
+	}
+}
+
+//register then unregister a metric in a registry already holding n
+func BenchmarkRegistryRegisterUnregister(b *testing.B) {
+	for _, n := range registrySizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			r := populated(b, n)
+			c := metrics.NewCounter()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := r.Register("extra", c); err != nil {
+					b.Fatal(err)
+				}
+				r.Unregister("extra")
+			}
+		})
+	}
+}
+
+func BenchmarkRegistryEach(b *testing.B) {
+	for _, n := range registrySizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			r := populated(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				count := 0
+				r.Each(func(string, interface{}) { count++ })
+				if count != n {
+					b.Fatalf("%d metrics, want %d", count, n)
+				}
+			}
+		})
+	}
+}
+
+//Each right after a registration,when the sorted index has to be rebuilt
+func BenchmarkRegistryEachAfterRegister(b *testing.B) {
+	for _, n := range registrySizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			r := populated(b, n)
+			c := metrics.NewCounter()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.Register("extra", c)
+				r.Each(func(string, interface{}) {})
+				r.Unregister("extra")
+			}
+		})
+	}
+}
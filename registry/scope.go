@@ -0,0 +1,160 @@
+package registry
+
+import (
+	"strings"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+/***
+ScopedRegistry is a view of the root registry restricted to the names starting with its scope,
+such as r.Scope("db").Scope("pool") for the names starting with db.pool.
+The metrics are stored in the root,so iterating the root includes the metrics of every scope.
+*/
+type ScopedRegistry struct {
+	root  Registry
+	scope string //full scope path without the trailing point,such as db.pool
+}
+
+//the name in the root registry
+func (s *ScopedRegistry) fullName(name string) string {
+	return s.scope + "." + name
+}
+
+//the name in the scope and whether the root name belongs to the scope
+func (s *ScopedRegistry) scopedName(name string) (string, bool) {
+	return trimScope(s.scope, name)
+}
+
+func trimScope(scope string, name string) (string, bool) {
+	if !strings.HasPrefix(name, scope+".") {
+		return "", false
+	}
+	return name[len(scope)+1:], true
+}
+
+func (s *ScopedRegistry) fullMetricName(name MetricName) MetricName {
+	return MetricName{Name: s.fullName(name.Name), Tags: name.Tags}
+}
+
+// Call the given function for each metric of the scope,with the name relative to the scope.
+func (s *ScopedRegistry) Each(f func(string, interface{})) {
+	s.root.Each(func(name string, i interface{}) {
+		if scoped, ok := s.scopedName(name); ok {
+			f(scoped, i)
+		}
+	})
+}
+
+func (s *ScopedRegistry) Get(name string) interface{} {
+	return s.root.Get(s.fullName(name))
+}
+
+func (s *ScopedRegistry) GetOrRegister(name string, i interface{}, opts ...MetricOption) interface{} {
+	return s.root.GetOrRegister(s.fullName(name), i, opts...)
+}
+
+func (s *ScopedRegistry) Register(name string, i interface{}, opts ...MetricOption) error {
+	return s.root.Register(s.fullName(name), i, opts...)
+}
+
+func (s *ScopedRegistry) Options(name string) MetricOptions {
+	return s.root.Options(s.fullName(name))
+}
+
+func (s *ScopedRegistry) Describe(name string) (MetricDescription, bool) {
+	d, ok := s.root.Describe(s.fullName(name))
+	if ok {
+		d.Name.Name, _ = s.scopedName(d.Name.Name)
+	}
+	return d, ok
+}
+
+func (s *ScopedRegistry) EachTagged(f func(MetricName, interface{})) {
+	s.root.EachTagged(func(name MetricName, i interface{}) {
+		if scoped, ok := s.scopedName(name.Name); ok {
+			f(MetricName{Name: scoped, Tags: name.Tags}, i)
+		}
+	})
+}
+
+func (s *ScopedRegistry) GetTagged(name MetricName) interface{} {
+	return s.root.GetTagged(s.fullMetricName(name))
+}
+
+func (s *ScopedRegistry) GetOrRegisterTagged(name MetricName, i interface{}, opts ...MetricOption) interface{} {
+	return s.root.GetOrRegisterTagged(s.fullMetricName(name), i, opts...)
+}
+
+func (s *ScopedRegistry) RegisterTagged(name MetricName, i interface{}, opts ...MetricOption) error {
+	return s.root.RegisterTagged(s.fullMetricName(name), i, opts...)
+}
+
+func (s *ScopedRegistry) UnregisterTagged(name MetricName) {
+	s.root.UnregisterTagged(s.fullMetricName(name))
+}
+
+func (s *ScopedRegistry) Unregister(name string) {
+	s.root.Unregister(s.fullName(name))
+}
+
+// Unregister all the metrics of the scope,the other metrics of the root are kept.
+func (s *ScopedRegistry) UnregisterAll() {
+	var names []MetricName
+	s.root.EachTagged(func(name MetricName, i interface{}) {
+		if _, ok := s.scopedName(name.Name); ok {
+			names = append(names, name)
+		}
+	})
+	for _, name := range names {
+		s.root.UnregisterTagged(name)
+	}
+}
+
+// Prefix is the universal prefix of the root followed by the scope,
+// so that reporters of the scope report the same names as reporters of the root.
+func (s *ScopedRegistry) Prefix() string {
+	if prefix := s.root.Prefix(); prefix != "" {
+		return prefix + "." + s.scope
+	}
+	return s.scope
+}
+
+func (s *ScopedRegistry) Scope(name string) Registry {
+	return &ScopedRegistry{root: s.root, scope: s.fullName(name)}
+}
+
+func (s *ScopedRegistry) UnregisterScope(name string) {
+	s.Scope(name).UnregisterAll()
+}
+
+// AddListener adds a listener notified of the metrics of the scope only,with the names relative to the scope.
+func (s *ScopedRegistry) AddListener(l RegistryListener) {
+	s.root.AddListener(scopedListener{scope: s.scope, listener: l})
+}
+
+func (s *ScopedRegistry) RemoveListener(l RegistryListener) {
+	s.root.RemoveListener(scopedListener{scope: s.scope, listener: l})
+}
+
+func (s *ScopedRegistry) MarshalJson() ([]byte, error) {
+	return s.MarshalJsonWithOptions(output.Options{})
+}
+
+func (s *ScopedRegistry) MarshalJsonWithOptions(o output.Options) ([]byte, error) {
+	return marshalJson(s, o)
+}
+
+// Names returns the names of the metrics of the scope relative to it,sorted.
+func (s *ScopedRegistry) Names() []string {
+	return sortedNames(s)
+}
+
+// Find returns the metrics of the scope whose base name relative to the scope matches the shell pattern.
+func (s *ScopedRegistry) Find(pattern string) map[string]interface{} {
+	return find(s, pattern)
+}
+
+func (s *ScopedRegistry) EachMatching(filter MetricFilter, f func(MetricName, interface{})) {
+	EachFiltered(s, filter, f)
+}
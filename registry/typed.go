@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//GetOrRegisterTyped returns the metric of type T registered under the name,registering the one built by create if there is none.
+//A *TypeMismatchError is returned if another kind of metric is registered under the name,it's also logged as a warning
+//by the Logger of the registry,a *LimitExceededError if the registry is full,unless it's configured to return the shared no-op metrics.
+func GetOrRegisterTyped[T any](r Registry, name string, create func() T, opts ...MetricOption) (T, error) {
+	return getOrRegisterTyped(loggerOf(r, name), name, func() interface{} { return r.Get(name) }, func(m T) error {
+		return r.Register(name, m, opts...)
+	}, create)
+}
+
+//GetOrRegisterTaggedTyped is just like GetOrRegisterTyped,but keyed by a tagged name
+func GetOrRegisterTaggedTyped[T any](r Registry, name MetricName, create func() T, opts ...MetricOption) (T, error) {
+	return getOrRegisterTyped(loggerOf(r, name.Name), name.String(), func() interface{} { return r.GetTagged(name) }, func(m T) error {
+		return r.RegisterTagged(name, m, opts...)
+	}, create)
+}
+
+func getOrRegisterTyped[T any](logger Logger, name string, get func() interface{}, register func(T) error, create func() T) (T, error) {
+	if existing := get(); existing != nil {
+		return typed[T](logger, name, existing)
+	}
+	m := create()
+	if err := register(m); err != nil {
+		if _, ok := err.(*DuplicateMetricError); ok {
+			//registered concurrently,use the winner if it is of the same type
+			if existing := get(); existing != nil {
+				return typed[T](logger, name, existing)
+			}
+		}
+		if limitErr, ok := err.(*LimitExceededError); ok && limitErr.Fallback != nil {
+			if noop, ok := limitErr.Fallback.(T); ok {
+				return noop, nil
+			}
+		}
+		var zero T
+		return zero, err
+	}
+	return m, nil
+}
+
+func typed[T any](logger Logger, name string, existing interface{}) (T, error) {
+	if m, ok := existing.(T); ok {
+		return m, nil
+	}
+	var zero T
+	err := &TypeMismatchError{Name: name, Existing: KindOf(existing), Wanted: fmt.Sprintf("%T", (*T)(nil))[1:]}
+	logger.Warn("metric type mismatch", "name", name, "kind", err.Existing.String(), "wanted", err.Wanted)
+	return zero, err
+}
+
+//LoggerOf returns the Logger of the registry holding the name,see loggerOf
+func LoggerOf(r Registry, name string) Logger {
+	return loggerOf(r, name)
+}
+
+//loggerOf returns the Logger of the registry holding the name: the one of the root of scopes,the one of the registry
+//a composite mounts the name from,NopLogger if there is none
+func loggerOf(r Registry, name string) Logger {
+	switch reg := r.(type) {
+	case *StandardRegistry:
+		return reg.log()
+	case *ScopedRegistry:
+		return loggerOf(reg.root, reg.fullName(name))
+	case *CompositeRegistry:
+		if m, n, ok := reg.locate(name); ok {
+			return loggerOf(m.registry, n)
+		}
+		if m, n, ok := reg.target(name); ok {
+			return loggerOf(m.registry, n)
+		}
+	}
+	return output.NopLogger
+}
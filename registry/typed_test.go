@@ -0,0 +1,172 @@
+package registry
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+)
+
+//recordingLogger keeps the messages logged at each level
+type recordingLogger struct {
+	mutex    sync.Mutex
+	messages map[string][]string
+}
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{messages: make(map[string][]string)}
+}
+
+func (l *recordingLogger) record(level, msg string, args []interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.messages[level] = append(l.messages[level], fmt.Sprint(append([]interface{}{msg}, args...)...))
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.record("debug", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.record("info", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.record("warn", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.record("error", msg, args) }
+
+func (l *recordingLogger) logged(level string) []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]string(nil), l.messages[level]...)
+}
+
+func TestGetOrRegisterTyped(t *testing.T) {
+	r := NewRegistry()
+	created := 0
+	newCounter := func() mechanism.Counter {
+		created++
+		return metrics.NewCounter()
+	}
+	c, err := GetOrRegisterTyped(r, "requests", newCounter)
+	if err != nil || c == nil || r.Get("requests") != c {
+		t.Fatalf("GetOrRegisterTyped() = %v, %v, want the registered counter", c, err)
+	}
+	again, err := GetOrRegisterTyped(r, "requests", newCounter)
+	if err != nil || again != c {
+		t.Fatalf("GetOrRegisterTyped() = %v, %v, want the counter registered first", again, err)
+	}
+	if created != 1 {
+		t.Fatalf("created %d counters, want 1", created)
+	}
+}
+
+func TestGetOrRegisterTypedMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry func(logger Logger) Registry
+		get      func(r Registry) (mechanism.Counter, error)
+	}{
+		{"plain name", func(logger Logger) Registry {
+			r := NewRegistry().(*StandardRegistry)
+			r.SetLogger(logger)
+			r.Register("requests", metrics.NewFrozenGauge(1))
+			return r
+		}, func(r Registry) (mechanism.Counter, error) {
+			return GetOrRegisterTyped(r, "requests", metrics.NewCounter)
+		}},
+		{"tagged name", func(logger Logger) Registry {
+			r := NewRegistry().(*StandardRegistry)
+			r.SetLogger(logger)
+			r.RegisterTagged(NewMetricName("requests", "method", "GET"), metrics.NewFrozenGauge(1))
+			return r
+		}, func(r Registry) (mechanism.Counter, error) {
+			return GetOrRegisterTaggedTyped(r, NewMetricName("requests", "method", "GET"), metrics.NewCounter)
+		}},
+		{"scope", func(logger Logger) Registry {
+			r := NewRegistry().(*StandardRegistry)
+			r.SetLogger(logger)
+			r.Register("api.requests", metrics.NewFrozenGauge(1))
+			return r.Scope("api")
+		}, func(r Registry) (mechanism.Counter, error) {
+			return GetOrRegisterTyped(r, "requests", metrics.NewCounter)
+		}},
+		{"composite", func(logger Logger) Registry {
+			mounted := NewRegistry().(*StandardRegistry)
+			mounted.SetLogger(logger)
+			mounted.Register("requests", metrics.NewFrozenGauge(1))
+			c := NewCompositeRegistry()
+			c.Mount("api", mounted)
+			return c
+		}, func(r Registry) (mechanism.Counter, error) {
+			return GetOrRegisterTyped(r, "api.requests", metrics.NewCounter)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := newRecordingLogger()
+			c, err := tt.get(tt.registry(logger))
+			mismatch, ok := err.(*TypeMismatchError)
+			if !ok {
+				t.Fatalf("error = %v, want a *TypeMismatchError", err)
+			}
+			if c != nil {
+				t.Errorf("counter = %v, want nil", c)
+			}
+			if mismatch.Existing != KindGauge || mismatch.Wanted != "mechanism.Counter" {
+				t.Errorf("error = %+v, want a gauge registered instead of a mechanism.Counter", mismatch)
+			}
+			if warnings := logger.logged("warn"); len(warnings) != 1 {
+				t.Errorf("warnings = %q, want the mismatch", warnings)
+			}
+		})
+	}
+}
+
+func TestGetOrRegisterTypedConcurrently(t *testing.T) {
+	r := NewRegistry()
+	const goroutines = 32
+	counters := make([]mechanism.Counter, goroutines)
+	var wg sync.WaitGroup
+	for i := range counters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := GetOrRegisterTyped(r, "requests", metrics.NewCounter)
+			if err != nil {
+				t.Error(err)
+			}
+			counters[i] = c
+		}(i)
+	}
+	wg.Wait()
+	for _, c := range counters {
+		if c != counters[0] || c != r.Get("requests") {
+			t.Fatal("concurrent callers got different counters")
+		}
+	}
+}
+
+func TestGetOrRegisterTypedLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		action LimitAction
+		noop   bool
+	}{
+		{"reject", LimitReject, false},
+		{"noop", LimitNoop, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewLimitedRegistry(Limits{MaxMetrics: 1, Action: tt.action})
+			if _, err := GetOrRegisterTyped(r, "a", metrics.NewCounter); err != nil {
+				t.Fatal(err)
+			}
+			c, err := GetOrRegisterTyped(r, "b", metrics.NewCounter)
+			if tt.noop {
+				if _, ok := c.(metrics.NoopCounter); !ok || err != nil {
+					t.Fatalf("GetOrRegisterTyped() = %v, %v, want the no-op counter", c, err)
+				}
+				return
+			}
+			if _, ok := err.(*LimitExceededError); !ok || c != nil {
+				t.Fatalf("GetOrRegisterTyped() = %v, %v, want a *LimitExceededError", c, err)
+			}
+		})
+	}
+}
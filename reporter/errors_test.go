@@ -0,0 +1,58 @@
+package reporter
+
+import (
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/metrics"
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+var errWrite = errors.New("disk full")
+
+//failingWriter accepts n bytes then fails
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, errWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestReportersReturnWriteErrors(t *testing.T) {
+	r := registry.NewRegistry()
+	for _, name := range []string{"a", "b", "c"} {
+		r.Register(name, metrics.NewCounter())
+	}
+	tests := []struct {
+		name   string
+		report func(w *failingWriter) error
+	}{
+		{"WriteOnce", func(w *failingWriter) error { return WriteOnce(r, w) }},
+		{"writer reporter", func(w *failingWriter) error { return NewWriteReporter(r, time.Minute, w, Options{}).ReportNow() }},
+		{"log reporter", func(w *failingWriter) error {
+			return NewLogReporter(r, time.Minute, log.New(w, "", 0), Options{}).ReportNow()
+		}},
+		{"prometheus", func(w *failingWriter) error { return WritePrometheus(r, w, Options{}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, n := range []int{0, 10} {
+				if err := tt.report(&failingWriter{n: n}); err != errWrite {
+					t.Errorf("failing after %d bytes: error = %v, want %v", n, err, errWrite)
+				}
+			}
+			if err := tt.report(&failingWriter{n: 1 << 20}); err != nil {
+				t.Errorf("error = %v, want nil", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package reporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/carbin-gun/awesome-metrics/metrics"
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+//every reporter encodes the frame,which holds only the metrics matched by the filter of the options
+func TestReportersFilter(t *testing.T) {
+	r := registry.NewRegistry()
+	r.Register("kept", metrics.NewFrozenCounter(1))
+	r.Register("dropped", metrics.NewFrozenCounter(2))
+	o := Options{Filter: registry.Not(registry.NamePrefixFilter("drop"))}
+	tests := []struct {
+		name   string
+		report func(w *bytes.Buffer) error
+	}{
+		{"text", func(w *bytes.Buffer) error { return WriteOnceWithOptions(r, w, o) }},
+		{"prometheus", func(w *bytes.Buffer) error { return WritePrometheus(r, w, o) }},
+		{"json", func(w *bytes.Buffer) error {
+			data, err := r.MarshalJsonWithOptions(o)
+			w.Write(data)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.report(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); !strings.Contains(got, "kept") || strings.Contains(got, "dropped") {
+				t.Errorf("output doesn't hold only the kept metric:\n%s", got)
+			}
+		})
+	}
+}
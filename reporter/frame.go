@@ -0,0 +1,29 @@
+package reporter
+
+import (
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+//the frame is built by the registry package,so that MarshalJson encodes the same frame as the reporters
+
+type FieldKind = registry.FieldKind
+
+const (
+	StatField       = registry.StatField
+	PercentileField = registry.PercentileField
+	BucketField     = registry.BucketField
+	RateField       = registry.RateField
+)
+
+type (
+	Field       = registry.Field
+	MetricFrame = registry.MetricFrame
+	Frame       = registry.Frame
+)
+
+//NewFrame reads the metrics of the registry passing the filter of the options,see registry.NewFrame
+func NewFrame(r registry.Registry, o Options, now time.Time) *Frame {
+	return registry.NewFrame(r, o, now)
+}
@@ -0,0 +1,186 @@
+package reporter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/metrics"
+	"github.com/carbin-gun/awesome-metrics/output"
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+var goldenOptions = Options{DurationUnit: time.Millisecond, RateUnit: time.Minute, Percentiles: []float64{0.5, 0.99}}
+
+//a registry with a metric of every kind,tags,metadata and buckets
+func goldenRegistry() registry.Registry {
+	r := registry.NewPrefixRegistry("app")
+	rates := metrics.FrozenRates{Rate1: 0.5, Rate5: 0.25, Rate15: 0.125, RateMean: 1}
+	r.Register("jobs", metrics.NewFrozenCounter(42), registry.WithDescription("jobs run"), registry.WithUnit("jobs"), registry.WithMonotonic())
+	r.Register("queue", metrics.NewFrozenGauge(7))
+	r.Register("temperature", metrics.NewFrozenGauge64(21.5))
+	r.Register("hits", metrics.NewFrozenMeter(120, rates))
+	r.Register("db", metrics.NewFrozenHealthcheck(errors.New("connection refused")))
+	r.Register("ratio", metrics.NewFrozenHistogramFloat64(metrics.FrozenStats{
+		Count: 4, Sum: 2, Min: 0.25, Max: 0.75, Mean: 0.5, StdDev: 0.25,
+		Percentiles: map[float64]float64{0.5: 0.5, 0.99: 0.75},
+	}))
+	r.Register("sizes", metrics.NewFrozenHistogram(metrics.FrozenStats{
+		Count: 3, Sum: 600, Min: 100, Max: 300, Mean: 200, StdDev: 100,
+		Percentiles: map[float64]float64{0.5: 200, 0.99: 300},
+		Buckets:     []output.Bucket{{UpperBound: 128, Count: 1}, {UpperBound: 256, Count: 2}, {UpperBound: math.Inf(1), Count: 3}},
+	}))
+	r.RegisterTagged(registry.NewMetricName("latency", "route", "/api"), metrics.NewFrozenTimer(metrics.FrozenStats{
+		Count: 2, Sum: 3e6, Min: 1e6, Max: 2e6, Mean: 1.5e6, StdDev: 5e5,
+		Percentiles: map[float64]float64{0.5: 1e6, 0.99: 2e6},
+	}, rates), registry.WithDescription("request latency"))
+	//tags overriding the host and unit tags of OpenTSDB
+	r.RegisterTagged(registry.NewMetricName("cpu", "host", "web1", "unit", "cores"), metrics.NewFrozenGauge64(0.5), registry.WithUnit("ratio"))
+	//a summary in the family of the queue gauge for prometheus
+	r.RegisterTagged(registry.NewMetricName("queue", "shard", "1"), metrics.NewFrozenHistogram(metrics.FrozenStats{
+		Count: 1, Sum: 5, Min: 5, Max: 5, Mean: 5,
+		Percentiles: map[float64]float64{0.5: 5, 0.99: 5},
+	}))
+	//metrics of a nested scope,reported under its prefix by scopedFrame
+	pool := r.Scope("db").Scope("pool")
+	pool.Register("conns", metrics.NewFrozenCounter(3))
+	pool.RegisterTagged(registry.NewMetricName("wait", "shard", "1"), metrics.NewFrozenTimer(metrics.FrozenStats{
+		Count: 1, Sum: 4e6, Min: 4e6, Max: 4e6, Mean: 4e6,
+		Percentiles: map[float64]float64{0.5: 4e6, 0.99: 4e6},
+	}, rates))
+	return r
+}
+
+//the scope of goldenFrame whose metrics are reported by the scoped golden files
+func goldenScope() registry.Registry {
+	return goldenRegistry().Scope("db").Scope("pool")
+}
+
+func goldenFrame() *Frame {
+	return NewFrame(goldenRegistry(), goldenOptions, time.Unix(1700000000, 0))
+}
+
+func scopedFrame() *Frame {
+	return NewFrame(goldenScope(), goldenOptions, time.Unix(1700000000, 0))
+}
+
+//compare the output with the golden file,or rewrite it with -update
+func checkGolden(t *testing.T, file string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", file)
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s,run go test -update to accept it:\n%s", path, got)
+	}
+}
+
+func TestReportersGolden(t *testing.T) {
+	tests := []struct {
+		file   string
+		encode func(w *bytes.Buffer, frame *Frame) error
+	}{
+		{"text.txt", func(w *bytes.Buffer, frame *Frame) error { return writeText(w, frame) }},
+		{"log.txt", func(w *bytes.Buffer, frame *Frame) error { return logFrame(log.New(w, "metrics: ", 0), frame) }},
+		{"prometheus.txt", func(w *bytes.Buffer, frame *Frame) error { return writePrometheus(w, frame) }},
+		{"influx.txt", func(w *bytes.Buffer, frame *Frame) error {
+			writeInflux(&InfluxConfig{Tags: map[string]string{"host": "h1"}}, w, frame)
+			return nil
+		}},
+		{"syslog.txt", func(w *bytes.Buffer, frame *Frame) error {
+			for _, m := range frame.Metrics {
+				fmt.Fprintln(w, syslogLine(frame.Prefix, m))
+			}
+			return nil
+		}},
+		{"graphite.txt", func(w *bytes.Buffer, frame *Frame) error { return writeGraphite(bufio.NewWriter(w), frame, false) }},
+		{"graphite_tagged.txt", func(w *bytes.Buffer, frame *Frame) error { return writeGraphite(bufio.NewWriter(w), frame, true) }},
+		{"opentsdb.txt", func(w *bytes.Buffer, frame *Frame) error {
+			return writeOpenTSDB(bufio.NewWriter(w), frame, "pre", "host1")
+		}},
+		{"opentsdb_unprefixed.txt", func(w *bytes.Buffer, frame *Frame) error {
+			return writeOpenTSDB(bufio.NewWriter(w), frame, "", "host1")
+		}},
+		{"json.json", func(w *bytes.Buffer, frame *Frame) error {
+			data, err := json.MarshalIndent(frame, "", "  ")
+			w.Write(append(data, '\n'))
+			return err
+		}},
+	}
+	//the frame of a scope prefixes the names of its metrics by the full scope,scoped_*.txt check it
+	frames := []struct {
+		prefix string
+		frame  func() *Frame
+	}{
+		{"", goldenFrame},
+		{"scoped_", scopedFrame},
+	}
+	for _, f := range frames {
+		for _, tt := range tests {
+			t.Run(f.prefix+tt.file, func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := tt.encode(&buf, f.frame()); err != nil {
+					t.Fatal(err)
+				}
+				checkGolden(t, f.prefix+tt.file, buf.Bytes())
+			})
+		}
+	}
+}
+
+//MarshalJson encodes the frame the reporters encode,and ParseSnapshotJSON reads it back
+func TestMarshalJsonEncodesFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry registry.Registry
+		frame    *Frame
+	}{
+		{"root", goldenRegistry(), goldenFrame()},
+		{"scope", goldenScope(), scopedFrame()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.registry.MarshalJsonWithOptions(goldenOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			frame, err := json.Marshal(tt.frame)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, frame) {
+				t.Fatalf("MarshalJson() = %s\nwant the frame %s", data, frame)
+			}
+			parsed, err := registry.ParseSnapshotJSON(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := parsed.MarshalJsonWithOptions(goldenOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(again, data) {
+				t.Fatalf("MarshalJson() of the parsed registry = %s\nwant %s", again, data)
+			}
+		})
+	}
+}
@@ -1,104 +1,164 @@
 package reporter
+
 import (
-"net"
-	"time"
 	"bufio"
+	"context"
 	"fmt"
-	"log"
-	"github.com/carbin-gun/awesome-metrics/output"
-	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/carbin-gun/awesome-metrics/registry"
 )
 
-
-type GraphiteReporter struct{
-	Addr          *net.TCPAddr     // TCP Address of server
+type GraphiteReporter struct {
+	Addr          *net.TCPAddr      // TCP Address of server
 	Registry      registry.Registry // data collector
-	FlushInterval time.Duration    //data will flush from Registry to server address
-	DurationUnit  time.Duration    // Time unit of flush interval
-	Percentiles   []float64        // Percentiles to report from timers and histograms
+	FlushInterval time.Duration     //data will flush from Registry to server address
+	TaggedSeries  bool              // render tags as graphite tagged series,name;key=value,instead of appending their values to the path
+	DurationUnit  time.Duration     // Deprecated: set Options.DurationUnit,this one is used only if that one is zero
+	Percentiles   []float64         // Deprecated: set Options.Percentiles,these ones are used only if those ones are empty
+	Options                         // Percentiles,DurationUnit,RateUnit,Filter,Logger and Timeout of the reporter
+
+	once      sync.Once
+	scheduler *ScheduledReporter //created on first use from the settings above
 }
 
-//Report report data to server according to the FlushInterval
+//Report report data to server according to the FlushInterval,it blocks forever
 func (r *GraphiteReporter) Report() {
-	for _ = range time.Tick(r.FlushInterval) {
-		if err:=r.ReportOnce();nil!=err {
-			log.Println("report error:",err)
+	r.scheduled().Run(context.Background())
+}
+
+//Start reports every FlushInterval in the background until the context is done or Stop is called
+func (r *GraphiteReporter) Start(ctx context.Context) {
+	r.scheduled().Start(ctx)
+}
+
+//Stop stops the reports started by Start,after a final one
+func (r *GraphiteReporter) Stop() {
+	r.scheduled().Stop()
+}
+
+//ReportNow is ReportOnce,never run while a scheduled report runs
+func (r *GraphiteReporter) ReportNow() error {
+	return r.scheduled().ReportNow()
+}
+
+func (r *GraphiteReporter) scheduled() *ScheduledReporter {
+	r.once.Do(func() {
+		r.scheduler = &ScheduledReporter{
+			Name:     "graphite",
+			Interval: r.FlushInterval,
+			Report:   r.ReportOnce,
+			Logger:   r.Logger,
+			Fields:   []interface{}{"addr", r.Addr.String()},
+		}
+	})
+	return r.scheduler
+}
+
+//the sanitized path of the metric and the suffix of its tags.Tagged series are rendered as ;key=value,
+//otherwise the tag values are appended to the path in their order
+func graphiteName(n registry.MetricName, taggedSeries bool) (string, string) {
+	name, tags := graphitePath(n.Name), ""
+	for _, t := range n.Tags {
+		if taggedSeries {
+			tags += ";" + graphiteTagKey(t.Key) + "=" + graphiteTagValue(t.Value)
+		} else {
+			name += "." + graphiteSegment(t.Value)
 		}
 	}
+	return name, tags
 }
 
-//compute the report key universal prefix according to the registry if the registry with a prefix setting
-func computeReportPrefix(registry registry.Registry) string{
-	registryPrefix := registry.Prefix()
-	var keyPrefix string
-	if registryPrefix!="" {
-		keyPrefix = fmt.Sprintf("%s.",registryPrefix) //there should be a point between the universal prefix and the real key
+//format the upper bound of a bucket for metric names and tags,+Inf is written as inf
+func formatBucketBound(bound float64) string {
+	if math.IsInf(bound, 1) {
+		return "inf"
 	}
-	return keyPrefix
+	return strconv.FormatFloat(bound, 'f', -1, 64)
 }
 
-func outputPercentiles(w *bufio.Writer ,prefix string,name string,snapshot output.Snapshot,currentTime time.Time) {
-	p75:=snapshot.Get75thPercentile()
-	p95:=snapshot.Get95thPercentile()
-	p98:=snapshot.Get98thPercentile()
-	p99:=snapshot.Get99thPercentile()
-	p999:=snapshot.Get999thPercentile()
-	fmt.Fprintf(w, "%s%s.75-percentile %.2f %d\n", prefix, name, p75, currentTime)
-	fmt.Fprintf(w, "%s%s.95-percentile %.2f %d\n", prefix, name, p95, currentTime)
-	fmt.Fprintf(w, "%s%s.98-percentile %.2f %d\n", prefix, name, p98, currentTime)
-	fmt.Fprintf(w, "%s%s.99-percentile %.2f %d\n", prefix, name, p99, currentTime)
-	fmt.Fprintf(w, "%s%s.999-percentile %.2f %d\n", prefix, name, p999, currentTime)
+var pathLabels = map[string]string{"stddev": "std-dev", "rate1": "one-minute", "rate5": "five-minute", "rate15": "fifteen-minute"}
+
+//the last segment of the path of the field,shared by graphite and opentsdb
+func pathLabel(kind registry.MetricKind, f Field) string {
+	if f.Name == "rate_mean" {
+		return meanRateLabel(kind, "mean-rate")
+	}
+	if label, ok := pathLabels[f.Name]; ok {
+		return label
+	}
+	return f.Name
+}
+
+//the value of the field for graphite and opentsdb,computed floats are rounded to 2 decimals
+func pathValue(f Field) string {
+	switch {
+	case f.Integer:
+		return strconv.FormatInt(f.Int, 10)
+	case f.Recorded:
+		return fmt.Sprintf("%f", f.Float)
+	}
+	return fmt.Sprintf("%.2f", f.Float)
+}
+
+//dial the server of a report,the connection and the writes are limited by the timeout of the options
+func dial(addr *net.TCPAddr, o Options) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr.String(), o.Timeouts())
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.SetWriteDeadline(time.Now().Add(o.Timeouts())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
 }
 
-//Report report data to server instantly
-func (r *GraphiteReporter) ReportOnce() error{
-	conn, err := net.DialTCP("tcp", nil, r.Addr)
+//Report report data to server instantly,returning the first error dialing or writing to it
+func (r *GraphiteReporter) ReportOnce() error {
+	o := r.options()
+	conn, err := dial(r.Addr, o)
 	if nil != err {
 		return err
 	}
 	defer conn.Close()
-	w := bufio.NewWriter(conn)
-	now:=time.Now()
-	du := float64(r.DurationUnit)
-	keyPrefix:= computeReportPrefix(r.Registry)
-	r.Registry.Each(func(name string, i interface{}) {
-		switch metric := i.(type) {
-		case mechanism.Counter:
-			fmt.Fprintf(w, "%s%s.count %d %d\n", keyPrefix, name, metric.Count(), now)
-		case mechanism.Gauge:
-			fmt.Fprintf(w, "%s%s.value %d %d\n", keyPrefix, name, metric.Value(), now)
-		case mechanism.Gauge64:
-			fmt.Fprintf(w, "%s%s.value %f %d\n", keyPrefix, name, metric.Value(), now)
-		case mechanism.Histogram:
-			h := metric.Snapshot()
-			fmt.Fprintf(w, "%s%s.count %d %d\n", keyPrefix, name, metric.Count(), now)
-			fmt.Fprintf(w, "%s%s.min %d %d\n", keyPrefix, name, h.Min(), now)
-			fmt.Fprintf(w, "%s%s.max %d %d\n", keyPrefix, name, h.Max(), now)
-			fmt.Fprintf(w, "%s%s.mean %.2f %d\n", keyPrefix, name, h.Mean(), now)
-			fmt.Fprintf(w, "%s%s.std-dev %.2f %d\n", keyPrefix, name, h.StdDev(), now)
-			outputPercentiles(w,keyPrefix,name,h,now)
-		case mechanism.Meter:
-			fmt.Fprintf(w, "%s%s.count %d %d\n", keyPrefix, name, metric.Count(), now)
-			fmt.Fprintf(w, "%s%s.one-minute %.2f %d\n", keyPrefix, name, metric.Rate1(), now)
-			fmt.Fprintf(w, "%s%s.five-minute %.2f %d\n", keyPrefix, name, metric.Rate5(), now)
-			fmt.Fprintf(w, "%s%s.fifteen-minute %.2f %d\n", keyPrefix, name, metric.Rate15(), now)
-			fmt.Fprintf(w, "%s%s.mean %.2f %d\n", keyPrefix, name, metric.RateMean(), now)
-		case mechanism.Timer:
-			t := metric.Snapshot()
-			fmt.Fprintf(w, "%s%s.count %d %d\n", keyPrefix, name, metric.Count(), now)
-			fmt.Fprintf(w, "%s%s.min %d %d\n", keyPrefix, name, t.Min()/int64(du), now)
-			fmt.Fprintf(w, "%s%s.max %d %d\n", keyPrefix, name, t.Max()/int64(du), now)
-			fmt.Fprintf(w, "%s%s.mean %.2f %d\n", keyPrefix, name, t.Mean()/du, now)
-			fmt.Fprintf(w, "%s%s.std-dev %.2f %d\n", keyPrefix, name, t.StdDev()/du, now)
-			outputPercentiles(w,keyPrefix,name,h,now)
-			fmt.Fprintf(w, "%s%s.one-minute %.2f %d\n", keyPrefix, name, metric.Rate1(), now)
-			fmt.Fprintf(w, "%s%s.five-minute %.2f %d\n", keyPrefix, name, metric.Rate5(), now)
-			fmt.Fprintf(w, "%s%s.fifteen-minute %.2f %d\n", keyPrefix, name, metric.Rate15(), now)
-			fmt.Fprintf(w, "%s%s.mean-rate %.2f %d\n", keyPrefix, name, metric.RateMean(), now)
+	return writeGraphite(bufio.NewWriter(conn), NewFrame(r.Registry, o, time.Now()), r.TaggedSeries)
+}
+
+//the Options completed by the deprecated fields
+func (r *GraphiteReporter) options() Options {
+	o := r.Options
+	if o.DurationUnit == 0 {
+		o.DurationUnit = r.DurationUnit
+	}
+	if len(o.Percentiles) == 0 {
+		o.Percentiles = r.Percentiles
+	}
+	return o
+}
+
+//write the frame in the plaintext protocol,flushed after every metric
+func writeGraphite(w *bufio.Writer, frame *Frame, taggedSeries bool) error {
+	now := frame.Time.Unix()
+	keyPrefix := graphitePath(frame.Prefix)
+	for _, m := range frame.Metrics {
+		name, tags := graphiteName(m.Name, taggedSeries)
+		for _, f := range m.Fields {
+			if f.Kind == BucketField {
+				bound := strings.Replace(formatBucketBound(f.Bound), ".", "_", -1)
+				fmt.Fprintf(w, "%s%s.bucket.le_%s%s %d %d\n", keyPrefix, name, bound, tags, f.Int, now)
+				continue
+			}
+			fmt.Fprintf(w, "%s%s.%s%s %s %d\n", keyPrefix, name, pathLabel(m.Kind, f), tags, pathValue(f), now)
 		}
-		w.Flush()
-	})
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
-}
\ No newline at end of file
@@ -0,0 +1,123 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+// InfluxConfig provides a container with configuration parameters for
+// the InfluxDB exporter
+type InfluxConfig struct {
+	URL           string            // Base URL of the server,such as http://localhost:8086
+	Database      string            // Database to write the points to
+	Registry      registry.Registry // Registry to be exported
+	FlushInterval time.Duration     // Flush interval
+	Tags          map[string]string // Tags added to every point,such as host
+	Options                         // Percentiles,DurationUnit,RateUnit,Filter,Logger and Timeout of the reporter
+}
+
+// Influx is a blocking exporter function which writes the metrics of the
+// registry to InfluxDB with the line protocol every FlushInterval.
+func Influx(c InfluxConfig) {
+	NewInfluxReporter(c).Run(context.Background())
+}
+
+// NewInfluxReporter creates a reporter writing the metrics of the registry to InfluxDB every FlushInterval.
+func NewInfluxReporter(c InfluxConfig) *ScheduledReporter {
+	return &ScheduledReporter{
+		Name:     "influx",
+		Interval: c.FlushInterval,
+		Report:   func() error { return influx(&c) },
+		Logger:   c.Logger,
+		Fields:   []interface{}{"url", c.URL, "database", c.Database},
+	}
+}
+
+func influx(c *InfluxConfig) error {
+	var buf bytes.Buffer
+	writeInflux(c, &buf, NewFrame(c.Registry, c.Options, time.Now()))
+	client := &http.Client{Timeout: c.Timeouts()}
+	resp, err := client.Post(c.URL+"/write?db="+url.QueryEscape(c.Database), "text/plain", &buf)
+	if nil != err {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("influx write failed,status:%s,body:%s", resp.Status, body)
+	}
+	return nil
+}
+
+//render the tags of the config and of the name as ",key=value",sorted by key as influx recommends
+func influxTags(c *InfluxConfig, m MetricFrame) string {
+	tags := make(map[string]string, len(c.Tags)+len(m.Name.Tags)+1)
+	for k, v := range c.Tags {
+		tags[influxTag(k)] = influxTag(v)
+	}
+	if m.Description.Unit != "" {
+		tags["unit"] = influxTag(m.Description.Unit)
+	}
+	for _, t := range m.Name.Tags {
+		tags[influxTag(t.Key)] = influxTag(t.Value)
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&buf, ",%s=%s", k, tags[k])
+	}
+	return buf.String()
+}
+
+var influxFields = map[string]string{"rate1": "m1", "rate5": "m5", "rate15": "m15"}
+
+//render the fields as count=2i,sum=3,...,integers are suffixed by i
+func influxFieldSet(m MetricFrame) string {
+	var buf bytes.Buffer
+	for _, f := range m.Fields {
+		if f.Kind == BucketField {
+			continue
+		}
+		key, ok := influxFields[f.Name]
+		if !ok {
+			key = f.Name
+		}
+		if f.Name == "rate_mean" {
+			key = meanRateLabel(m.Kind, "meanrate")
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte(',')
+		}
+		if f.Integer {
+			fmt.Fprintf(&buf, "%s=%di", key, f.Int)
+		} else {
+			fmt.Fprintf(&buf, "%s=%g", key, f.Float)
+		}
+	}
+	return buf.String()
+}
+
+//write one point per metric of the frame in the line protocol,measurement,tags fields timestamp
+func writeInflux(c *InfluxConfig, w io.Writer, frame *Frame) {
+	ts := frame.Time.UnixNano()
+	for _, m := range frame.Metrics {
+		fields := influxFieldSet(m)
+		if fields == "" {
+			continue
+		}
+		fmt.Fprintf(w, "%s%s %s %d\n", influxMeasurement(frame.Prefix+m.Name.Name), influxTags(c, m), fields, ts)
+	}
+}
@@ -2,15 +2,14 @@ package reporter
 
 import (
 	"bufio"
+	"context"
 	"fmt"
-	"log"
 	"net"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/carbin-gun/awesome-metrics/registry"
-	"github.com/carbin-gun/awesome-metrics/mechanism"
 )
 
 var shortHostName string = ""
@@ -21,8 +20,9 @@ type OpenTSDBConfig struct {
 	Addr          *net.TCPAddr      // Network address to connect to
 	Registry      registry.Registry // Registry to be exported
 	FlushInterval time.Duration     // Flush interval
-	DurationUnit  time.Duration     // Time conversion unit for durations
-	Prefix        string            // Prefix to be prepended to metric names
+	Prefix        string            // Prefix to be prepended to metric names,before the prefix of the registry
+	DurationUnit  time.Duration     // Deprecated: set Options.DurationUnit,this one is used only if that one is zero
+	Options                         // Percentiles,DurationUnit,RateUnit,Filter,Logger and Timeout of the reporter
 }
 
 // OpenTSDB is a blocking exporter function which reports metrics in r
@@ -33,7 +33,6 @@ func OpenTSDB(r registry.Registry, d time.Duration, prefix string, addr *net.TCP
 		Addr:          addr,
 		Registry:      r,
 		FlushInterval: d,
-		DurationUnit:  time.Nanosecond,
 		Prefix:        prefix,
 	})
 }
@@ -41,10 +40,17 @@ func OpenTSDB(r registry.Registry, d time.Duration, prefix string, addr *net.TCP
 // OpenTSDBWithConfig is a blocking exporter function just like OpenTSDB,
 // but it takes a OpenTSDBConfig instead.
 func OpenTSDBWithConfig(c OpenTSDBConfig) {
-	for _ = range time.Tick(c.FlushInterval) {
-		if err := openTSDB(&c); nil != err {
-			log.Println(err)
-		}
+	NewOpenTSDBReporter(c).Run(context.Background())
+}
+
+// NewOpenTSDBReporter creates a reporter sending the metrics of the registry to a TSDB server every FlushInterval.
+func NewOpenTSDBReporter(c OpenTSDBConfig) *ScheduledReporter {
+	return &ScheduledReporter{
+		Name:     "opentsdb",
+		Interval: c.FlushInterval,
+		Report:   func() error { return openTSDB(&c) },
+		Logger:   c.Logger,
+		Fields:   []interface{}{"addr", c.Addr.String()},
 	}
 }
 
@@ -60,60 +66,65 @@ func getShortHostname() string {
 	return shortHostName
 }
 
+//render the tags of the metric as key=value separated by spaces: the host and unit ones,unless the name has tags
+//with the same keys which override them,then the tags of the name in the given order
+func openTSDBTags(shortHostname string, m MetricFrame) string {
+	own := make(map[string]bool, len(m.Name.Tags))
+	var tags []string
+	for _, t := range m.Name.Tags {
+		key := openTSDBName(t.Key)
+		own[key] = true
+		tags = append(tags, key+"="+openTSDBName(t.Value))
+	}
+	var generated []string
+	if !own["host"] {
+		generated = append(generated, "host="+openTSDBName(shortHostname))
+	}
+	if m.Description.Unit != "" && !own["unit"] {
+		generated = append(generated, "unit="+openTSDBName(m.Description.Unit))
+	}
+	return strings.Join(append(generated, tags...), " ")
+}
+
 func openTSDB(c *OpenTSDBConfig) error {
-	shortHostname := getShortHostname()
-	now := time.Now().Unix()
-	du := float64(c.DurationUnit)
-	conn, err := net.DialTCP("tcp", nil, c.Addr)
+	o := c.options()
+	frame := NewFrame(c.Registry, o, time.Now())
+	conn, err := dial(c.Addr, o)
 	if nil != err {
 		return err
 	}
 	defer conn.Close()
-	w := bufio.NewWriter(conn)
-	c.Registry.Each(func(name string, i interface{}) {
-		switch metric := i.(type) {
-		case mechanism.Counter:
-			fmt.Fprintf(w, "put %s.%s.count %d %d host=%s\n", c.Prefix, name, now, metric.Count(), shortHostname)
-		case mechanism.Gauge:
-			fmt.Fprintf(w, "put %s.%s.value %d %d host=%s\n", c.Prefix, name, now, metric.Value(), shortHostname)
-		case mechanism.Gauge64:
-			fmt.Fprintf(w, "put %s.%s.value %d %f host=%s\n", c.Prefix, name, now, metric.Value(), shortHostname)
-		case mechanism.Histogram:
-			h := metric.Snapshot()
-			fmt.Fprintf(w, "put %s.%s.count %d %d host=%s\n", c.Prefix, name, now, metric.Count(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.min %d %d host=%s\n", c.Prefix, name, now, h.Min(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.max %d %d host=%s\n", c.Prefix, name, now, h.Max(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.mean %d %.2f host=%s\n", c.Prefix, name, now, h.Mean(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.std-dev %d %.2f host=%s\n", c.Prefix, name, now, h.StdDev(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.50-percentile %d %.2f host=%s\n", c.Prefix, name, now, h.Median(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.75-percentile %d %.2f host=%s\n", c.Prefix, name, now, h.Get75thPercentile(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.95-percentile %d %.2f host=%s\n", c.Prefix, name, now, h.Get95thPercentile(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.99-percentile %d %.2f host=%s\n", c.Prefix, name, now, h.Get99thPercentile(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.999-percentile %d %.2f host=%s\n", c.Prefix, name, now, h.Get999thPercentile()), shortHostname)
-		case mechanism.Meter:
-			fmt.Fprintf(w, "put %s.%s.count %d %d host=%s\n", c.Prefix, name, now, metric.Count(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.one-minute %d %.2f host=%s\n", c.Prefix, name, now, metric.Rate1(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.five-minute %d %.2f host=%s\n", c.Prefix, name, now, metric.Rate5(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.fifteen-minute %d %.2f host=%s\n", c.Prefix, name, now, metric.Rate15(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.mean %d %.2f host=%s\n", c.Prefix, name, now, metric.RateMean(), shortHostname)
-		case mechanism.Timer:
-			t := metric.Snapshot()
-			fmt.Fprintf(w, "put %s.%s.count %d %d host=%s\n", c.Prefix, name, now, metric.Count(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.min %d %d host=%s\n", c.Prefix, name, now, t.Min()/int64(du), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.max %d %d host=%s\n", c.Prefix, name, now, t.Max()/int64(du), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.mean %d %.2f host=%s\n", c.Prefix, name, now, t.Mean()/du, shortHostname)
-			fmt.Fprintf(w, "put %s.%s.std-dev %d %.2f host=%s\n", c.Prefix, name, now, t.StdDev()/du, shortHostname)
-			fmt.Fprintf(w, "put %s.%s.50-percentile %d %.2f host=%s\n", c.Prefix, name, now, t.Median()/du, shortHostname)
-			fmt.Fprintf(w, "put %s.%s.75-percentile %d %.2f host=%s\n", c.Prefix, name, now, t.Get75thPercentile()/du, shortHostname)
-			fmt.Fprintf(w, "put %s.%s.95-percentile %d %.2f host=%s\n", c.Prefix, name, now, t.Get95thPercentile()/du, shortHostname)
-			fmt.Fprintf(w, "put %s.%s.99-percentile %d %.2f host=%s\n", c.Prefix, name, now, t.Get99thPercentile()/du, shortHostname)
-			fmt.Fprintf(w, "put %s.%s.999-percentile %d %.2f host=%s\n", c.Prefix, name, now, t.Get999thPercentile()/du, shortHostname)
-			fmt.Fprintf(w, "put %s.%s.one-minute %d %.2f host=%s\n", c.Prefix, name, now, metric.Rate1(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.five-minute %d %.2f host=%s\n", c.Prefix, name, now, metric.Rate5(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.fifteen-minute %d %.2f host=%s\n", c.Prefix, name, now, metric.Rate15(), shortHostname)
-			fmt.Fprintf(w, "put %s.%s.mean-rate %d %.2f host=%s\n", c.Prefix, name, now, metric.RateMean(), shortHostname)
+	return writeOpenTSDB(bufio.NewWriter(conn), frame, c.Prefix, getShortHostname())
+}
+
+//the Options completed by the deprecated fields
+func (c *OpenTSDBConfig) options() Options {
+	o := c.Options
+	if o.DurationUnit == 0 {
+		o.DurationUnit = c.DurationUnit
+	}
+	return o
+}
+
+//write the frame as put commands of the host,flushed after every metric
+func writeOpenTSDB(w *bufio.Writer, frame *Frame, prefix, shortHostname string) error {
+	now := frame.Time.Unix()
+	if prefix != "" {
+		prefix = openTSDBName(prefix) + "."
+	}
+	for _, m := range frame.Metrics {
+		name := prefix + openTSDBName(frame.Prefix+m.Name.Name)
+		tags := openTSDBTags(shortHostname, m)
+		for _, f := range m.Fields {
+			if f.Kind == BucketField {
+				fmt.Fprintf(w, "put %s.bucket %d %d %s le=%s\n", name, now, f.Int, tags, formatBucketBound(f.Bound))
+				continue
+			}
+			fmt.Fprintf(w, "put %s.%s %d %s %s\n", name, pathLabel(m.Kind, f), now, pathValue(f), tags)
 		}
-		w.Flush()
-	})
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
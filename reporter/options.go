@@ -0,0 +1,23 @@
+package reporter
+
+import (
+	"strings"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+// Options holds the settings shared by every reporter: percentiles,duration unit,rate unit,filter,logger and timeout.
+type Options = output.Options
+
+//the description and unit of the metric formatted as "  description: ..." lines,none if it has no metadata
+func metadataLines(d registry.MetricDescription) []string {
+	var lines []string
+	if d.Description != "" {
+		lines = append(lines, "  description: "+strings.Replace(d.Description, "\n", " ", -1))
+	}
+	if d.Unit != "" {
+		lines = append(lines, "  unit:        "+d.Unit)
+	}
+	return lines
+}
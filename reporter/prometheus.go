@@ -0,0 +1,169 @@
+package reporter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+//a metric and the prometheus family it belongs to
+type prometheusMetric struct {
+	family string
+	kind   string
+	MetricFrame
+}
+
+// PrometheusHandler serves the metrics of the registry in the prometheus
+// text exposition format,it is usually mounted on /metrics.
+func PrometheusHandler(r registry.Registry, o Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := WritePrometheus(r, w, o); err != nil {
+			o.Log().Error("report failed", "reporter", "prometheus", "error", err)
+		}
+	})
+}
+
+// WritePrometheus writes the metrics of the registry in the prometheus text
+// exposition format.Tags are rendered as labels,counters and gauges as gauges,
+// meters and monotonic counters as counters,sampled histograms and timers as
+// summaries and bucket histograms as histograms.Descriptions are rendered as HELP.
+// It returns the first write error.
+func WritePrometheus(r registry.Registry, w io.Writer, o Options) error {
+	return writePrometheus(w, NewFrame(r, o, time.Now()))
+}
+
+//write the frame in the text exposition format
+func writePrometheus(out io.Writer, frame *Frame) error {
+	var metrics []prometheusMetric
+	for _, m := range frame.Metrics {
+		family := prometheusName(frame.Prefix + m.Name.Name)
+		switch m.Kind {
+		case registry.KindCounter:
+			if m.Description.Monotonic {
+				metrics = append(metrics, prometheusMetric{family + "_total", "counter", m})
+			} else {
+				metrics = append(metrics, prometheusMetric{family, "gauge", m})
+			}
+		case registry.KindGauge, registry.KindGauge64:
+			metrics = append(metrics, prometheusMetric{family, "gauge", m})
+		case registry.KindMeter:
+			metrics = append(metrics, prometheusMetric{family + "_total", "counter", m})
+		case registry.KindHistogram, registry.KindTimer:
+			metrics = append(metrics, prometheusMetric{family, prometheusHistogramKind(m), m})
+		case registry.KindHistogramFloat64:
+			metrics = append(metrics, prometheusMetric{family, "summary", m})
+		}
+	}
+	//a family has a single type,the metrics of another type than the first one of their family get a family of their own
+	kinds := make(map[string]string, len(metrics))
+	for i, pm := range metrics {
+		if kind, ok := kinds[pm.family]; !ok {
+			kinds[pm.family] = pm.kind
+		} else if kind != pm.kind {
+			metrics[i].family += "_" + pm.kind
+		}
+	}
+	sort.SliceStable(metrics, func(i, j int) bool { return metrics[i].family < metrics[j].family })
+
+	w := bufio.NewWriter(out)
+	var lastFamily string
+	for _, pm := range metrics {
+		if pm.family != lastFamily {
+			if help := pm.Description.Description; help != "" {
+				fmt.Fprintf(w, "# HELP %s %s\n", pm.family, prometheusHelpEscaper.Replace(help))
+			}
+			fmt.Fprintf(w, "# TYPE %s %s\n", pm.family, pm.kind)
+			lastFamily = pm.family
+		}
+		switch pm.kind {
+		case "histogram", "summary":
+			writePrometheusDistribution(w, pm.family, pm.MetricFrame)
+		default:
+			value := pm.Fields[0]
+			if value.Integer {
+				fmt.Fprintf(w, "%s%s %d\n", pm.family, prometheusLabels(pm.Name.Tags), value.Int)
+			} else {
+				fmt.Fprintf(w, "%s%s %s\n", pm.family, prometheusLabels(pm.Name.Tags), prometheusFloat(value.Float))
+			}
+		}
+	}
+	return w.Flush()
+}
+
+//histogram if the metric has fixed buckets,summary otherwise
+func prometheusHistogramKind(m MetricFrame) string {
+	if m.Bucketed() {
+		return "histogram"
+	}
+	return "summary"
+}
+
+//write the buckets or the quantiles of the metric followed by _sum and _count
+func writePrometheusDistribution(w io.Writer, family string, m MetricFrame) {
+	tags, bucketed := m.Name.Tags, m.Bucketed()
+	for _, f := range m.Fields {
+		switch {
+		case f.Kind == BucketField:
+			le := "+Inf"
+			if !math.IsInf(f.Bound, 1) {
+				le = prometheusFloat(f.Bound)
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", family, prometheusLabels(tags, "le", le), f.Int)
+		case f.Kind == PercentileField && !bucketed:
+			fmt.Fprintf(w, "%s%s %s\n", family, prometheusLabels(tags, "quantile", prometheusFloat(f.Quantile)), prometheusFloat(f.Float))
+		}
+	}
+	sum, _ := m.Field("sum")
+	count, _ := m.Field("count")
+	fmt.Fprintf(w, "%s_sum%s %s\n", family, prometheusLabels(tags), prometheusFloat(sum.Value()))
+	fmt.Fprintf(w, "%s_count%s %d\n", family, prometheusLabels(tags), count.Int)
+}
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+var prometheusHelpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+//render the tags and the extra alternating keys and values as {key="value"},empty if there is none
+func prometheusLabels(tags []registry.Tag, extra ...string) string {
+	if len(tags) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, t := range tags {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, `%s="%s"`, prometheusLabelName(t.Key), prometheusLabelEscaper.Replace(t.Value))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, `%s="%s"`, extra[i], prometheusLabelEscaper.Replace(extra[i+1]))
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+func prometheusFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
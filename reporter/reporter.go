@@ -0,0 +1,110 @@
+package reporter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/output"
+)
+
+//DefaultInterval is the time between two reports of the reporters configured with a non-positive interval
+const DefaultInterval = time.Minute
+
+//Reporter reports a registry on a schedule until stopped
+type Reporter interface {
+	//Start reports every interval in the background until the context is done or Stop is called,
+	//calling it again while started does nothing
+	Start(ctx context.Context)
+	//Stop stops the reports and waits for the final one
+	Stop()
+	//ReportNow reports at once,never while another report of the reporter runs
+	ReportNow() error
+}
+
+//ScheduledReporter calls Report every Interval,one report at a time,and a last time when stopped,
+//so that the values since the last tick aren't lost on shutdown.
+//The reporters of this package are ScheduledReporters around their report function.
+type ScheduledReporter struct {
+	Name     string        //name of the reporter in the logged failures,such as graphite
+	Interval time.Duration //time between two reports,DefaultInterval if not positive
+	Report   func() error  //reports the registry once
+	Logger   output.Logger //receives the report failures,silent if nil
+	Fields   []interface{} //logged with every failure,such as "addr", addr
+
+	reportMutex sync.Mutex //serializes the reports
+	mutex       sync.Mutex //guards cancel and done
+	cancel      context.CancelFunc
+	done        chan struct{} //closed when the started reporter is stopped,nil if not started
+}
+
+//Start reports in the background until the context is done or Stop is called,
+//the reporter can be started again once stopped either way
+func (s *ScheduledReporter) Start(ctx context.Context) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.done != nil {
+		return
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	go func(cancel context.CancelFunc, done chan struct{}) {
+		defer close(done)
+		s.Run(ctx)
+		cancel()
+		s.mutex.Lock()
+		if s.done == done {
+			s.cancel, s.done = nil, nil
+		}
+		s.mutex.Unlock()
+	}(s.cancel, s.done)
+}
+
+//Stop stops the reports and waits for the final one,the mutex isn't held while waiting,
+//so that concurrent calls to Stop all wait for it and ReportNow isn't blocked
+func (s *ScheduledReporter) Stop() {
+	s.mutex.Lock()
+	cancel, done := s.cancel, s.done
+	s.mutex.Unlock()
+	if done == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+func (s *ScheduledReporter) ReportNow() error {
+	s.reportMutex.Lock()
+	defer s.reportMutex.Unlock()
+	return s.Report()
+}
+
+//Run reports every Interval until the context is done,then reports a last time.
+//It blocks,Start runs it in the background.
+func (s *ScheduledReporter) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.reportAndLog()
+		case <-ctx.Done():
+			s.reportAndLog()
+			return
+		}
+	}
+}
+
+func (s *ScheduledReporter) reportAndLog() {
+	if err := s.ReportNow(); err != nil {
+		logger := s.Logger
+		if logger == nil {
+			logger = output.NopLogger
+		}
+		logger.Error("report failed", append([]interface{}{"reporter", s.Name, "error", err}, s.Fields...)...)
+	}
+}
@@ -0,0 +1,137 @@
+package reporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+func TestScheduledReporterIntervals(t *testing.T) {
+	for _, interval := range []time.Duration{-time.Second, 0, time.Hour} {
+		var reports int32
+		s := &ScheduledReporter{Name: "test", Interval: interval, Report: func() error {
+			atomic.AddInt32(&reports, 1)
+			return nil
+		}}
+		s.Start(context.Background())
+		s.Stop()
+		if n := atomic.LoadInt32(&reports); n != 1 {
+			t.Errorf("interval %v: %d reports, want the final one", interval, n)
+		}
+	}
+}
+
+//a reporter counting its reports
+func countingReporter(reports *int32) *ScheduledReporter {
+	return &ScheduledReporter{Name: "test", Interval: time.Hour, Report: func() error {
+		atomic.AddInt32(reports, 1)
+		return nil
+	}}
+}
+
+func started(s *ScheduledReporter) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.done != nil
+}
+
+//wait until the reporter made n reports,failing after a second
+func waitReports(t *testing.T, reports *int32, n int32) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(reports) < n; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d reports,want %d", atomic.LoadInt32(reports), n)
+		}
+	}
+}
+
+func TestScheduledReporterRestartsAfterCancel(t *testing.T) {
+	var reports int32
+	s := countingReporter(&reports)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	cancel()
+	for deadline := time.Now().Add(time.Second); started(s); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the reporter is still started after the context is done")
+		}
+	}
+	s.Start(context.Background())
+	s.Stop()
+	if n := atomic.LoadInt32(&reports); n != 2 {
+		t.Errorf("%d reports,want the final one of each start", n)
+	}
+}
+
+func TestScheduledReporterStopTwice(t *testing.T) {
+	var reports int32
+	s := countingReporter(&reports)
+	s.Start(context.Background())
+	s.Stop()
+	s.Stop()
+	if n := atomic.LoadInt32(&reports); n != 1 {
+		t.Errorf("%d reports,want only the final one", n)
+	}
+}
+
+func TestScheduledReporterReportNowDuringStop(t *testing.T) {
+	var reports int32
+	release := make(chan struct{})
+	s := &ScheduledReporter{Name: "test", Interval: time.Hour, Report: func() error {
+		if atomic.AddInt32(&reports, 1) == 1 {
+			<-release
+		}
+		return nil
+	}}
+	s.Start(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	waitReports(t, &reports, 1)
+	reported := make(chan error)
+	go func() {
+		reported <- s.ReportNow()
+	}()
+	close(release)
+	select {
+	case err := <-reported:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ReportNow blocked by Stop")
+	}
+	<-stopped
+	if n := atomic.LoadInt32(&reports); n != 2 {
+		t.Errorf("%d reports,want the final one and ReportNow", n)
+	}
+}
+
+func TestInfluxReportTimesOut(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+	rep := NewInfluxReporter(InfluxConfig{URL: server.URL, Registry: registry.NewRegistry(), Options: Options{Timeout: 10 * time.Millisecond}})
+	reported := make(chan error)
+	go func() {
+		reported <- rep.ReportNow()
+	}()
+	select {
+	case err := <-reported:
+		if err == nil {
+			t.Error("no error from a server not answering")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the report isn't limited by the timeout")
+	}
+}
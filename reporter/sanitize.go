@@ -0,0 +1,108 @@
+package reporter
+
+import (
+	"strings"
+	"unicode"
+)
+
+//sanitize replaces the runes not kept by keep with '_'
+func sanitize(s string, keep func(i int, c rune) bool) string {
+	i := -1
+	return strings.Map(func(c rune) rune {
+		i++
+		if keep(i, c) {
+			return c
+		}
+		return '_'
+	}, s)
+}
+
+func asciiAlphanumeric(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+//graphitePath keeps the points of the path,the other characters are letters,digits,'_','-' and ':'
+func graphitePath(s string) string {
+	return sanitize(s, func(_ int, c rune) bool {
+		return asciiAlphanumeric(c) || c == '_' || c == '-' || c == ':' || c == '.'
+	})
+}
+
+//graphiteSegment is a single node of the path,such as a tag value appended to it
+func graphiteSegment(s string) string {
+	return strings.Replace(graphitePath(s), ".", "_", -1)
+}
+
+//graphiteTagKey can't contain ';','!','^','=' or '~',nor spaces breaking the line
+func graphiteTagKey(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return sanitize(s, func(_ int, c rune) bool {
+		return unicode.IsPrint(c) && !unicode.IsSpace(c) && !strings.ContainsRune(";!^=~", c)
+	})
+}
+
+//graphiteTagValue can't be empty,start with '~' or contain ';' or spaces breaking the line
+func graphiteTagValue(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return sanitize(s, func(i int, c rune) bool {
+		return unicode.IsPrint(c) && !unicode.IsSpace(c) && c != ';' && !(i == 0 && c == '~')
+	})
+}
+
+//openTSDBName is made of letters,digits,'-','_','.' and '/',it's used for metric names,tag keys and tag values
+func openTSDBName(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return sanitize(s, func(_ int, c rune) bool {
+		return asciiAlphanumeric(c) || unicode.IsLetter(c) || strings.ContainsRune("-_./", c)
+	})
+}
+
+//prometheusName matches [a-zA-Z_:][a-zA-Z0-9_:]*,points and the other characters become '_',a leading digit is prefixed by '_'
+func prometheusName(s string) string {
+	if s == "" {
+		return "_"
+	}
+	if s[0] >= '0' && s[0] <= '9' {
+		s = "_" + s
+	}
+	return sanitize(s, func(i int, c rune) bool {
+		return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == ':' || i > 0 && c >= '0' && c <= '9'
+	})
+}
+
+//prometheusLabelName matches [a-zA-Z_][a-zA-Z0-9_]*
+func prometheusLabelName(s string) string {
+	if s == "" {
+		return "_"
+	}
+	if s[0] >= '0' && s[0] <= '9' {
+		s = "_" + s
+	}
+	return sanitize(s, func(i int, c rune) bool {
+		return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || i > 0 && c >= '0' && c <= '9'
+	})
+}
+
+//line breaks can't be escaped in the line protocol,they are replaced by spaces before escaping
+var influxMeasurementEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, " ", `\ `, "\n", `\ `, "\r", `\ `)
+
+var influxTagEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, "=", `\=`, " ", `\ `, "\n", `\ `, "\r", `\ `)
+
+//influxMeasurement escapes the commas and spaces of the measurement
+func influxMeasurement(s string) string {
+	return influxMeasurementEscaper.Replace(s)
+}
+
+//influxTag escapes the commas,equal signs and spaces of a tag key or value,empty values aren't allowed
+func influxTag(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return influxTagEscaper.Replace(s)
+}
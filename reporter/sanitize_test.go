@@ -0,0 +1,107 @@
+package reporter
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+func TestSanitizers(t *testing.T) {
+	tests := []struct {
+		name     string
+		sanitize func(string) string
+		grammar  *regexp.Regexp //every sanitized string must match it
+		cases    map[string]string
+	}{
+		{"prometheus name", prometheusName, regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`), map[string]string{
+			"http.requests": "http_requests",
+			"2xx":           "_2xx",
+			"":              "_",
+			"lat-ms p99":    "lat_ms_p99",
+			"café":          "caf_",
+			"ns:total":      "ns:total",
+			"a{b=\"c\"}":    "a_b__c__",
+		}},
+		{"prometheus label name", prometheusLabelName, regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`), map[string]string{
+			"a:b":        "a_b",
+			"0le":        "_0le",
+			"":           "_",
+			"route/path": "route_path",
+			"__name__":   "__name__",
+		}},
+		{"graphite path", graphitePath, regexp.MustCompile(`^[a-zA-Z0-9_\-:.]*$`), map[string]string{
+			"a b/c":         "a_b_c",
+			"db.query;x=y":  "db.query_x_y",
+			"日本":            "__",
+			"host:8080.up":  "host:8080.up",
+			"line\nbreak":   "line_break",
+			"tab\tand\"quo": "tab_and_quo",
+		}},
+		{"graphite segment", graphiteSegment, regexp.MustCompile(`^[a-zA-Z0-9_\-:]*$`), map[string]string{
+			"10.0.0.1": "10_0_0_1",
+			"a b":      "a_b",
+		}},
+		{"graphite tag key", graphiteTagKey, regexp.MustCompile(`^[^;!^=~\s]+$`), map[string]string{
+			"a;b=c~": "a_b_c_",
+			"":       "_",
+			"a b":    "a_b",
+			"x!^y":   "x__y",
+		}},
+		{"graphite tag value", graphiteTagValue, regexp.MustCompile(`^[^~;\s][^;\s]*$`), map[string]string{
+			"~x;y z": "_x_y_z",
+			"":       "_",
+			"a~b=c":  "a~b=c",
+			"a\nb":   "a_b",
+		}},
+		{"opentsdb", openTSDBName, regexp.MustCompile(`^[\p{L}0-9\-_./]+$`), map[string]string{
+			"a b:c":   "a_b_c",
+			"":        "_",
+			"über/ms": "über/ms",
+			"a=b,c":   "a_b_c",
+			"x\ny":    "x_y",
+		}},
+		{"influx measurement", influxMeasurement, regexp.MustCompile(`^([^\\, \n\r]|\\[\\, ])+$`), map[string]string{
+			"cpu load,host": `cpu\ load\,host`,
+			"a\nb\rc":       `a\ b\ c`,
+			`a\b`:           `a\\b`,
+			"a=b":           "a=b",
+		}},
+		{"influx tag", influxTag, regexp.MustCompile(`^([^\\,= \n\r]|\\[\\,= ])+$`), map[string]string{
+			"a=b, c": `a\=b\,\ c`,
+			"":       "_",
+			`c:\dir`: `c:\\dir`,
+			"x\ny":   `x\ y`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for in, want := range tt.cases {
+				got := tt.sanitize(in)
+				if got != want {
+					t.Errorf("%s(%q) = %q, want %q", tt.name, in, got, want)
+				}
+				if !tt.grammar.MatchString(got) {
+					t.Errorf("%s(%q) = %q doesn't match %s", tt.name, in, got, tt.grammar)
+				}
+			}
+		})
+	}
+}
+
+func TestGraphiteName(t *testing.T) {
+	n := registry.NewMetricName("db query", "host", "10.0.0.1", "a;b", "~c d")
+	tests := []struct {
+		taggedSeries bool
+		name, tags   string
+	}{
+		{false, "db_query.10_0_0_1._c_d", ""},
+		{true, "db_query", ";host=10.0.0.1;a_b=_c_d"},
+	}
+	for _, tt := range tests {
+		name, tags := graphiteName(n, tt.taggedSeries)
+		if name != tt.name || tags != tt.tags {
+			t.Errorf("graphiteName(%v, %v) = %q, %q, want %q, %q", n, tt.taggedSeries, name, tags, tt.name, tt.tags)
+		}
+	}
+}
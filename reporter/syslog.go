@@ -1,73 +1,86 @@
 package reporter
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"log/syslog"
 	"time"
 
-	"github.com/carbin-gun/awesome-metrics/mechanism"
 	"github.com/carbin-gun/awesome-metrics/registry"
 )
 
 // Output each metric in the given registry to syslog periodically using
 // the given syslogger.
 func Syslog(r registry.Registry, d time.Duration, w *syslog.Writer) {
-	for _ = range time.Tick(d) {
-		r.Each(func(name string, i interface{}) {
-			switch metric := i.(type) {
-			case mechanism.Counter:
-				w.Info(fmt.Sprintf("counter %s: count: %d", name, metric.Count()))
-			case mechanism.Gauge:
-				w.Info(fmt.Sprintf("gauge %s: value: %d", name, metric.Value()))
-			case mechanism.Gauge64:
-				w.Info(fmt.Sprintf("gauge %s: value: %f", name, metric.Value()))
-			case mechanism.Histogram:
-				h := metric.Snapshot()
-				w.Info(fmt.Sprintf(
-					"histogram %s: count: %d min: %d max: %d mean: %.2f stddev: %.2f median: %.2f 75%%: %.2f 95%%: %.2f 99%%: %.2f 99.9%%: %.2f",
-					name,
-					metric.Count(),
-					h.Min(),
-					h.Max(),
-					h.Mean(),
-					h.StdDev(),
-					h.Median(),
-					h.Get75thPercentile(),
-					h.Get95thPercentile(),
-					h.Get99thPercentile(),
-					h.Get999thPercentile(),
-				))
-			case mechanism.Meter:
-				w.Info(fmt.Sprintf(
-					"meter %s: count: %d 1-min: %.2f 5-min: %.2f 15-min: %.2f mean: %.2f",
-					name,
-					metric.Count(),
-					metric.Rate1(),
-					metric.Rate5(),
-					metric.Rate15(),
-					metric.RateMean(),
-				))
-			case mechanism.Timer:
-				t := metric.Snapshot()
-				w.Info(fmt.Sprintf(
-					"timer %s: count: %d min: %d max: %d mean: %.2f stddev: %.2f median: %.2f 75%%: %.2f 95%%: %.2f 99%%: %.2f 99.9%%: %.2f 1-min: %.2f 5-min: %.2f 15-min: %.2f mean-rate: %.2f",
-					name,
-					metric.Count(),
-					t.Min(),
-					t.Max(),
-					t.Mean(),
-					t.StdDev(),
-					t.Median(),
-					t.Get75thPercentile(),
-					t.Get95thPercentile(),
-					t.Get99thPercentile(),
-					t.Get999thPercentile(),
-					metric.Rate1(),
-					metric.Rate5(),
-					metric.Rate15(),
-					metric.RateMean(),
-				))
+	SyslogWithOptions(r, d, w, Options{})
+}
+
+// SyslogWithOptions is just like Syslog,but it takes the reporting Options.
+func SyslogWithOptions(r registry.Registry, d time.Duration, w *syslog.Writer, o Options) {
+	NewSyslogReporter(r, d, w, o).Run(context.Background())
+}
+
+// NewSyslogReporter creates a reporter outputting each metric of the registry to syslog every d.
+func NewSyslogReporter(r registry.Registry, d time.Duration, w *syslog.Writer, o Options) *ScheduledReporter {
+	return &ScheduledReporter{Name: "syslog", Interval: d, Logger: o.Logger, Report: func() error {
+		return syslogOnce(r, w, o)
+	}}
+}
+
+//output each metric of the registry to syslog once,stopping at the first error of the writer
+func syslogOnce(r registry.Registry, w *syslog.Writer, o Options) error {
+	frame := NewFrame(r, o, time.Now())
+	for _, m := range frame.Metrics {
+		if err := w.Info(syslogLine(frame.Prefix, m)); err != nil {
+			return err
+		}
+		if d := m.Description; d.Description != "" || d.Unit != "" {
+			if err := w.Info(fmt.Sprintf("metadata %s%s: description: %s unit: %s", frame.Prefix, m.Key, d.Description, d.Unit)); err != nil {
+				return err
 			}
-		})
+		}
+	}
+	return nil
+}
+
+var syslogLabels = map[string]string{"rate1": "1-min", "rate5": "5-min", "rate15": "15-min"}
+
+//format the metric as "kind name: field: value ...",such as "timer t: count: 2 sum: 3.00ms ... mean-rate: 1.00/s",
+//the name is prefixed by the prefix of the frame
+func syslogLine(prefix string, m MetricFrame) string {
+	if m.Kind == registry.KindHealthcheck {
+		return fmt.Sprintf("healthcheck %s%s: error: %v", prefix, m.Key, m.Err)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s%s:", textKind(m.Kind), prefix, m.Key)
+	for _, f := range m.Fields {
+		if f.Kind == BucketField {
+			continue
+		}
+		label, ok := syslogLabels[f.Name]
+		if !ok {
+			label = f.Name
+		}
+		if f.Name == "rate_mean" {
+			label = meanRateLabel(m.Kind, "mean-rate")
+		}
+		switch {
+		case f.Integer:
+			fmt.Fprintf(&buf, " %s: %d%s", label, f.Int, f.Unit)
+		case m.Kind == registry.KindGauge64:
+			fmt.Fprintf(&buf, " %s: %f%s", label, f.Float, f.Unit)
+		default:
+			fmt.Fprintf(&buf, " %s: %.2f%s", label, f.Float, f.Unit)
+		}
+	}
+	return buf.String()
+}
+
+//the label of the mean rate,meters call it mean as they have no other mean
+func meanRateLabel(kind registry.MetricKind, timerLabel string) string {
+	if kind == registry.KindMeter {
+		return "mean"
 	}
+	return timerLabel
 }
@@ -0,0 +1,75 @@
+package reporter
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carbin-gun/awesome-metrics/metrics"
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+//serve one connection on a local address and return what report wrote to it
+func captureTCP(t *testing.T, report func(addr *net.TCPAddr) error) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		if c, err := l.Accept(); err == nil {
+			io.Copy(&buf, c)
+			c.Close()
+		}
+		received <- buf.String()
+	}()
+	if err := report(l.Addr().(*net.TCPAddr)); err != nil {
+		t.Fatal(err)
+	}
+	return <-received
+}
+
+//the deprecated unit fields of the graphite and OpenTSDB configs still apply,unless the Options set their own.
+//The conversions themselves are covered by the golden files
+func TestDeprecatedUnitFields(t *testing.T) {
+	r := registry.NewRegistry()
+	r.Register("t", metrics.NewFrozenTimer(metrics.FrozenStats{
+		Count: 2, Sum: 3e6, Min: 1e6, Max: 2e6, Mean: 1.5e6, StdDev: 5e5,
+		Percentiles: map[float64]float64{0.5: 1e6, 0.9: 2e6},
+	}, metrics.FrozenRates{}))
+	tests := []struct {
+		name   string
+		report func(addr *net.TCPAddr) error
+		want   []string
+	}{
+		{"graphite", func(addr *net.TCPAddr) error {
+			return (&GraphiteReporter{Addr: addr, Registry: r, DurationUnit: time.Millisecond, Percentiles: []float64{0.9}}).ReportOnce()
+		}, []string{"t.sum 3.00 ", "t.p90 2.00 "}},
+		{"graphite options first", func(addr *net.TCPAddr) error {
+			return (&GraphiteReporter{Addr: addr, Registry: r, DurationUnit: time.Millisecond, Percentiles: []float64{0.9},
+				Options: Options{DurationUnit: time.Microsecond, Percentiles: []float64{0.5}}}).ReportOnce()
+		}, []string{"t.sum 3000.00 ", "t.p50 1000.00 "}},
+		{"opentsdb", func(addr *net.TCPAddr) error {
+			return openTSDB(&OpenTSDBConfig{Addr: addr, Registry: r, DurationUnit: time.Millisecond})
+		}, []string{"put t.min ", " 1.00 host="}},
+		{"opentsdb options first", func(addr *net.TCPAddr) error {
+			return openTSDB(&OpenTSDBConfig{Addr: addr, Registry: r, DurationUnit: time.Millisecond, Options: Options{DurationUnit: time.Second}})
+		}, []string{"put t.min ", " 0.00 host="}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := captureTCP(t, tt.report)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("output doesn't contain %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
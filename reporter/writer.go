@@ -1,97 +1,91 @@
 package reporter
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"io"
-	"sort"
 	"time"
 
-	"github.com/carbin-gun/awesome-metrics/mechanism"
 	"github.com/carbin-gun/awesome-metrics/registry"
 )
 
 // Write sorts writes each metric in the given registry periodically to the
 // given io.Writer.
 func Write(r registry.Registry, d time.Duration, w io.Writer) {
-	for _ = range time.Tick(d) {
-		WriteOnce(r, w)
-	}
+	WriteWithOptions(r, d, w, Options{})
 }
 
-// WriteOnce sorts and writes metrics in the given registry to the given
-// io.Writer.
-func WriteOnce(r registry.Registry, w io.Writer) {
-	var namedMetrics namedMetricSlice
-	r.Each(func(name string, i interface{}) {
-		namedMetrics = append(namedMetrics, namedMetric{name, i})
-	})
+// WriteWithOptions is just like Write,but it takes the reporting Options.
+func WriteWithOptions(r registry.Registry, d time.Duration, w io.Writer, o Options) {
+	NewWriteReporter(r, d, w, o).Run(context.Background())
+}
 
-	sort.Sort(namedMetrics)
-	for _, namedMetric := range namedMetrics {
-		switch metric := namedMetric.m.(type) {
-		case mechanism.Counter:
-			fmt.Fprintf(w, "counter %s\n", namedMetric.name)
-			fmt.Fprintf(w, "  count:       %9d\n", metric.Count())
-		case mechanism.Gauge:
-			fmt.Fprintf(w, "gauge %s\n", namedMetric.name)
-			fmt.Fprintf(w, "  value:       %9d\n", metric.Value())
-		case mechanism.Gauge64:
-			fmt.Fprintf(w, "gauge %s\n", namedMetric.name)
-			fmt.Fprintf(w, "  value:       %f\n", metric.Value())
+// NewWriteReporter creates a reporter writing the metrics of the registry like WriteOnceWithOptions every d.
+func NewWriteReporter(r registry.Registry, d time.Duration, w io.Writer, o Options) *ScheduledReporter {
+	return &ScheduledReporter{Name: "writer", Interval: d, Logger: o.Logger, Report: func() error {
+		return WriteOnceWithOptions(r, w, o)
+	}}
+}
 
-		case mechanism.Histogram:
-			h := metric.Snapshot()
-			fmt.Fprintf(w, "histogram %s\n", namedMetric.name)
-			fmt.Fprintf(w, "  count:       %9d\n", metric.Count())
-			fmt.Fprintf(w, "  min:         %9d\n", h.Min())
-			fmt.Fprintf(w, "  max:         %9d\n", h.Max())
-			fmt.Fprintf(w, "  mean:        %12.2f\n", h.Mean())
-			fmt.Fprintf(w, "  stddev:      %12.2f\n", h.StdDev())
-			fmt.Fprintf(w, "  median:      %12.2f\n", h.Median())
-			fmt.Fprintf(w, "  75%%:         %12.2f\n", h.Get75thPercentile())
-			fmt.Fprintf(w, "  95%%:         %12.2f\n", h.Get95thPercentile())
-			fmt.Fprintf(w, "  99%%:         %12.2f\n", h.Get99thPercentile())
-			fmt.Fprintf(w, "  99.9%%:       %12.2f\n", h.Get999thPercentile())
-		case mechanism.Meter:
-			fmt.Fprintf(w, "meter %s\n", namedMetric.name)
-			fmt.Fprintf(w, "  count:       %9d\n", metric.Count())
-			fmt.Fprintf(w, "  1-min rate:  %12.2f\n", metric.Rate1())
-			fmt.Fprintf(w, "  5-min rate:  %12.2f\n", metric.Rate5())
-			fmt.Fprintf(w, "  15-min rate: %12.2f\n", metric.Rate15())
-			fmt.Fprintf(w, "  mean rate:   %12.2f\n", metric.RateMean())
-		case mechanism.Timer:
-			t := metric.Snapshot()
-			fmt.Fprintf(w, "timer %s\n", namedMetric.name)
-			fmt.Fprintf(w, "  count:       %9d\n", metric.Count())
-			fmt.Fprintf(w, "  min:         %9d\n", t.Min())
-			fmt.Fprintf(w, "  max:         %9d\n", t.Max())
-			fmt.Fprintf(w, "  mean:        %12.2f\n", t.Mean())
-			fmt.Fprintf(w, "  stddev:      %12.2f\n", t.StdDev())
-			fmt.Fprintf(w, "  median:      %12.2f\n", t.Median())
-			fmt.Fprintf(w, "  75%%:         %12.2f\n", t.Get75thPercentile())
-			fmt.Fprintf(w, "  95%%:         %12.2f\n", t.Get95thPercentile())
-			fmt.Fprintf(w, "  99%%:         %12.2f\n", t.Get99thPercentile())
-			fmt.Fprintf(w, "  99.9%%:       %12.2f\n", t.Get999thPercentile())
-			fmt.Fprintf(w, "  1-min rate:  %12.2f\n", metric.Rate1())
-			fmt.Fprintf(w, "  5-min rate:  %12.2f\n", metric.Rate5())
-			fmt.Fprintf(w, "  15-min rate: %12.2f\n", metric.Rate15())
-			fmt.Fprintf(w, "  mean rate:   %12.2f\n", metric.RateMean())
-		}
-	}
+// WriteOnce sorts and writes metrics in the given registry to the given
+// io.Writer,returning the first write error.
+func WriteOnce(r registry.Registry, w io.Writer) error {
+	return WriteOnceWithOptions(r, w, Options{})
 }
 
-type namedMetric struct {
-	name string
-	m    interface{}
+// WriteOnceWithOptions is just like WriteOnce,but it takes the reporting Options.
+func WriteOnceWithOptions(r registry.Registry, w io.Writer, o Options) error {
+	return writeText(w, NewFrame(r, o, time.Now()))
 }
 
-// namedMetricSlice is a slice of namedMetrics that implements sort.Interface.
-type namedMetricSlice []namedMetric
+//write the text lines of the frame
+func writeText(w io.Writer, frame *Frame) error {
+	//the buffered writer keeps the first error and skips the writes after it
+	bw := bufio.NewWriter(w)
+	for _, m := range frame.Metrics {
+		for _, line := range textLines(frame.Prefix, m) {
+			fmt.Fprintln(bw, line)
+		}
+	}
+	return bw.Flush()
+}
 
-func (nms namedMetricSlice) Len() int { return len(nms) }
+var textLabels = map[string]string{"rate1": "1-min rate", "rate5": "5-min rate", "rate15": "15-min rate", "rate_mean": "mean rate"}
 
-func (nms namedMetricSlice) Swap(i, j int) { nms[i], nms[j] = nms[j], nms[i] }
+//the lines of the metric written by the writer and log reporters,a "kind name" header followed by one line per field
+//and the metadata lines.The name is prefixed by the prefix of the frame like in every other reporter
+func textLines(prefix string, m MetricFrame) []string {
+	lines := []string{textKind(m.Kind) + " " + prefix + m.Key}
+	if m.Kind == registry.KindHealthcheck {
+		lines = append(lines, fmt.Sprintf("  error:       %v", m.Err))
+	}
+	for _, f := range m.Fields {
+		if f.Kind == BucketField {
+			continue
+		}
+		label, ok := textLabels[f.Name]
+		if !ok {
+			label = f.Name
+		}
+		var value string
+		switch {
+		case f.Integer:
+			value = fmt.Sprintf("%9d", f.Int)
+		case m.Kind == registry.KindGauge64:
+			value = fmt.Sprintf("%f", f.Float)
+		default:
+			value = fmt.Sprintf("%12.2f", f.Float)
+		}
+		lines = append(lines, fmt.Sprintf("  %-13s%s%s", label+":", value, f.Unit))
+	}
+	return append(lines, metadataLines(m.Description)...)
+}
 
-func (nms namedMetricSlice) Less(i, j int) bool {
-	return nms[i].name < nms[j].name
+//the kind written by the text reporters,float64 gauges are written as gauges
+func textKind(kind registry.MetricKind) string {
+	if kind == registry.KindGauge64 {
+		return registry.KindGauge.String()
+	}
+	return kind.String()
 }
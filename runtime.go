@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+//runtimeMemStats are the gauges registered by RegisterRuntimeMemStats,named runtime.MemStats.<field> as the fields they read
+var runtimeMemStats = []struct {
+	name  string
+	value func(*runtime.MemStats) int64
+}{
+	{"runtime.MemStats.Alloc", func(m *runtime.MemStats) int64 { return int64(m.Alloc) }},
+	{"runtime.MemStats.TotalAlloc", func(m *runtime.MemStats) int64 { return int64(m.TotalAlloc) }},
+	{"runtime.MemStats.Sys", func(m *runtime.MemStats) int64 { return int64(m.Sys) }},
+	{"runtime.MemStats.Mallocs", func(m *runtime.MemStats) int64 { return int64(m.Mallocs) }},
+	{"runtime.MemStats.Frees", func(m *runtime.MemStats) int64 { return int64(m.Frees) }},
+	{"runtime.MemStats.HeapAlloc", func(m *runtime.MemStats) int64 { return int64(m.HeapAlloc) }},
+	{"runtime.MemStats.HeapSys", func(m *runtime.MemStats) int64 { return int64(m.HeapSys) }},
+	{"runtime.MemStats.HeapIdle", func(m *runtime.MemStats) int64 { return int64(m.HeapIdle) }},
+	{"runtime.MemStats.HeapInuse", func(m *runtime.MemStats) int64 { return int64(m.HeapInuse) }},
+	{"runtime.MemStats.HeapReleased", func(m *runtime.MemStats) int64 { return int64(m.HeapReleased) }},
+	{"runtime.MemStats.HeapObjects", func(m *runtime.MemStats) int64 { return int64(m.HeapObjects) }},
+	{"runtime.MemStats.StackInuse", func(m *runtime.MemStats) int64 { return int64(m.StackInuse) }},
+	{"runtime.MemStats.NextGC", func(m *runtime.MemStats) int64 { return int64(m.NextGC) }},
+	{"runtime.MemStats.LastGC", func(m *runtime.MemStats) int64 { return int64(m.LastGC) }},
+	{"runtime.MemStats.PauseTotalNs", func(m *runtime.MemStats) int64 { return int64(m.PauseTotalNs) }},
+	{"runtime.MemStats.NumGC", func(m *runtime.MemStats) int64 { return int64(m.NumGC) }},
+	{"runtime.NumGoroutine", func(*runtime.MemStats) int64 { return int64(runtime.NumGoroutine()) }},
+	{"runtime.NumCgoCall", func(*runtime.MemStats) int64 { return runtime.NumCgoCall() }},
+}
+
+//RegisterRuntimeMemStats registers the gauges of the memory statistics of the runtime,updated by CaptureRuntimeMemStats
+func RegisterRuntimeMemStats(r *RegistryWrapper) {
+	for _, s := range runtimeMemStats {
+		r.Gauge(s.name)
+	}
+}
+
+//CaptureRuntimeMemStats updates the gauges of RegisterRuntimeMemStats every d,it blocks.
+//Reading the statistics stops the world,so d shouldn't be below a few seconds
+func CaptureRuntimeMemStats(r *RegistryWrapper, d time.Duration) {
+	for range time.Tick(d) {
+		CaptureRuntimeMemStatsOnce(r)
+	}
+}
+
+//CaptureRuntimeMemStatsOnce updates the gauges of RegisterRuntimeMemStats once
+func CaptureRuntimeMemStatsOnce(r *RegistryWrapper) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	for _, s := range runtimeMemStats {
+		r.Gauge(s.name).Update(s.value(&m))
+	}
+}
+
+//debugGCStats are the gauges registered by RegisterDebugGCStats,named debug.GCStats.<field> as the fields they read
+var debugGCStats = []struct {
+	name  string
+	value func(*debug.GCStats) int64
+}{
+	{"debug.GCStats.LastGC", func(s *debug.GCStats) int64 {
+		if s.LastGC.IsZero() {
+			return 0
+		}
+		return s.LastGC.UnixNano()
+	}},
+	{"debug.GCStats.NumGC", func(s *debug.GCStats) int64 { return s.NumGC }},
+	{"debug.GCStats.PauseTotal", func(s *debug.GCStats) int64 { return int64(s.PauseTotal) }},
+}
+
+//RegisterDebugGCStats registers the gauges of the garbage collection statistics,updated by CaptureDebugGCStats
+func RegisterDebugGCStats(r *RegistryWrapper) {
+	for _, s := range debugGCStats {
+		r.Gauge(s.name)
+	}
+}
+
+//CaptureDebugGCStats updates the gauges of RegisterDebugGCStats every d,it blocks
+func CaptureDebugGCStats(r *RegistryWrapper, d time.Duration) {
+	for range time.Tick(d) {
+		CaptureDebugGCStatsOnce(r)
+	}
+}
+
+//CaptureDebugGCStatsOnce updates the gauges of RegisterDebugGCStats once
+func CaptureDebugGCStatsOnce(r *RegistryWrapper) {
+	var stats debug.GCStats
+	debug.ReadGCStats(&stats)
+	for _, s := range debugGCStats {
+		r.Gauge(s.name).Update(s.value(&stats))
+	}
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestCaptureRuntimeStats(t *testing.T) {
+	r := NewRegistry()
+	RegisterRuntimeMemStats(r)
+	RegisterDebugGCStats(r)
+	if n := len(r.Registry.Names()); n != len(runtimeMemStats)+len(debugGCStats) {
+		t.Fatalf("%d metrics registered,want %d", n, len(runtimeMemStats)+len(debugGCStats))
+	}
+	runtime.GC()
+	CaptureRuntimeMemStatsOnce(r)
+	CaptureDebugGCStatsOnce(r)
+	for _, name := range []string{"runtime.MemStats.HeapAlloc", "runtime.MemStats.NumGC", "runtime.NumGoroutine", "debug.GCStats.NumGC", "debug.GCStats.LastGC"} {
+		if v := r.Gauge(name).Value(); v <= 0 {
+			t.Errorf("%s = %d,want it positive", name, v)
+		}
+	}
+}
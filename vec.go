@@ -0,0 +1,265 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/metrics"
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+//OverflowLabelValue is the value of every label of the child collecting the label values beyond the cardinality cap
+const OverflowLabelValue = "__overflow__"
+
+/***
+A family is a metric declared once with a label schema,its children are registered in the registry
+as tagged names,so that every reporter sees them as ordinary tagged metrics.
+At most maxChildren distinct label values are kept,the values beyond the cap are all recorded into the overflow child.
+The family listens to the registry,a child unregistered by any mean,such as expiry or UnregisterAll,frees its place.
+A family is declared once per name in a registry and its scopes,the wrapper keeps it so that it's listening only once.
+*/
+type family struct {
+	registry.RegistryListenerBase
+	registry    registry.Registry
+	defaults    *defaults //of the wrapper declaring the family,read whenever a child is created
+	kind        registry.MetricKind
+	name        string
+	labels      []string
+	maxChildren int //no cap if <= 0
+	mutex       sync.Mutex
+	children    map[string]registry.MetricName //by joined label values
+	keys        map[string]string              //joined label values by registry key of the child
+}
+
+//families are the families declared in a registry and its scopes,keyed by their full name,
+//so that declaring a family twice returns the first one instead of adding another listener
+type families struct {
+	mutex  sync.Mutex
+	byName map[string]*family
+}
+
+func newFamilies() *families {
+	return &families{byName: make(map[string]*family)}
+}
+
+//VecMismatchError is returned when declaring a family already declared under the name with another kind or other labels
+type VecMismatchError struct {
+	Name         string
+	Kind         registry.MetricKind //kind of the declared family
+	Labels       []string            //labels of the declared family
+	WantedKind   registry.MetricKind
+	WantedLabels []string
+}
+
+func (e *VecMismatchError) Error() string {
+	return fmt.Sprintf("vec mismatch: %s is declared as a %s vec labelled by %s,not a %s vec labelled by %s",
+		e.Name, e.Kind, strings.Join(e.Labels, ","), e.WantedKind, strings.Join(e.WantedLabels, ","))
+}
+
+//declare returns the family declared under the name,declaring it if there is none.
+//The cap is the one of the first declaration
+func (r *RegistryWrapper) declare(kind registry.MetricKind, name string, maxChildren int, labels []string) (*family, error) {
+	if r.families == nil {
+		r.families = newFamilies()
+	}
+	fs := r.families
+	key := name
+	if r.scope != "" {
+		key = r.scope + "." + name
+	}
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	if f, ok := fs.byName[key]; ok {
+		if f.kind != kind || !sameLabels(f.labels, labels) {
+			return nil, &VecMismatchError{Name: key, Kind: f.kind, Labels: f.labels, WantedKind: kind, WantedLabels: labels}
+		}
+		return f, nil
+	}
+	f := &family{
+		registry:    r.Registry,
+		defaults:    r.defaults,
+		kind:        kind,
+		name:        name,
+		labels:      labels,
+		maxChildren: maxChildren,
+		children:    make(map[string]registry.MetricName),
+		keys:        make(map[string]string),
+	}
+	r.Registry.AddListener(f)
+	fs.byName[key] = f
+	return f, nil
+}
+
+func sameLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//the name of the child,values beyond the cap fall into the overflow child.
+//It panics if the number of values doesn't match the labels,like a call with the wrong number of arguments
+func (f *family) childName(values []string) registry.MetricName {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s is labelled by %s,got %d label values", f.name, strings.Join(f.labels, ","), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if name, ok := f.children[key]; ok {
+		return name
+	}
+	if f.maxChildren > 0 && len(f.children) >= f.maxChildren {
+		return f.overflowName()
+	}
+	name := registry.MetricName{Name: f.name}
+	for i, label := range f.labels {
+		name = name.Tagged(label, values[i])
+	}
+	f.children[key] = name
+	f.keys[name.String()] = key
+	return name
+}
+
+//forget the child registered under the key,so that its label values count no more against the cap
+func (f *family) forget(key string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if values, ok := f.keys[key]; ok {
+		delete(f.keys, key)
+		delete(f.children, values)
+	}
+}
+
+func (f *family) OnCounterRemoved(name string, _ mechanism.Counter) {
+	f.forget(name)
+}
+
+func (f *family) OnTimerRemoved(name string, _ mechanism.Timer) {
+	f.forget(name)
+}
+
+func (f *family) OnHistogramRemoved(name string, _ mechanism.Histogram) {
+	f.forget(name)
+}
+
+func (f *family) overflowName() registry.MetricName {
+	name := registry.MetricName{Name: f.name}
+	for _, label := range f.labels {
+		name = name.Tagged(label, OverflowLabelValue)
+	}
+	return name
+}
+
+//the child of the given values built by create with the defaults of the family kind,
+//an unregistered one if it can't be registered,such as when another kind of metric holds its name.
+//The label values of an unregistered child don't count against the cap
+func familyChild[T any](f *family, values []string, create func(*metricConfig) T) T {
+	c := newMetricConfig(f.defaults.get(f.kind))
+	name := f.childName(values)
+	m, err := registry.GetOrRegisterTaggedTyped(f.registry, name, func() T { return create(c) }, c.metricOptions...)
+	if err != nil {
+		f.forget(name.String())
+		registry.LoggerOf(f.registry, name.Name).Warn("vec child not registered", "name", name.String(), "error", err)
+		return create(c)
+	}
+	return m
+}
+
+//unregister the child with the given label values,return false if there is no such child
+func (f *family) remove(values []string) bool {
+	key := strings.Join(values, "\xff")
+	f.mutex.Lock()
+	name, ok := f.children[key]
+	delete(f.children, key)
+	delete(f.keys, name.String())
+	f.mutex.Unlock()
+	if ok {
+		f.registry.UnregisterTagged(name)
+	}
+	return ok
+}
+
+//CounterVec is a family of counters partitioned by label values
+type CounterVec struct {
+	f *family
+}
+
+//WithLabelValues returns the counter of the given label values,in the order of the labels of the family
+func (v *CounterVec) WithLabelValues(values ...string) mechanism.Counter {
+	return familyChild(v.f, values, func(*metricConfig) mechanism.Counter {
+		return metrics.NewCounter()
+	})
+}
+
+//Remove unregisters the counter of the given label values
+func (v *CounterVec) Remove(values ...string) bool {
+	return v.f.remove(values)
+}
+
+//TimerVec is a family of timers partitioned by label values
+type TimerVec struct {
+	f *family
+}
+
+//WithLabelValues returns the timer of the given label values,in the order of the labels of the family
+func (v *TimerVec) WithLabelValues(values ...string) mechanism.Timer {
+	return familyChild(v.f, values, (*metricConfig).newTimer)
+}
+
+//Remove unregisters the timer of the given label values
+func (v *TimerVec) Remove(values ...string) bool {
+	return v.f.remove(values)
+}
+
+//HistogramVec is a family of histograms partitioned by label values
+type HistogramVec struct {
+	f *family
+}
+
+//WithLabelValues returns the histogram of the given label values,in the order of the labels of the family
+func (v *HistogramVec) WithLabelValues(values ...string) mechanism.Histogram {
+	return familyChild(v.f, values, (*metricConfig).newHistogram)
+}
+
+//Remove unregisters the histogram of the given label values
+func (v *HistogramVec) Remove(values ...string) bool {
+	return v.f.remove(values)
+}
+
+//CounterVec declares a family of counters with the given labels,keeping at most maxChildren label values if maxChildren > 0.
+//Declaring it again returns the same family,or a *VecMismatchError if it was declared with another kind or other labels
+func (r *RegistryWrapper) CounterVec(name string, maxChildren int, labels ...string) (*CounterVec, error) {
+	f, err := r.declare(registry.KindCounter, name, maxChildren, labels)
+	if err != nil {
+		return nil, err
+	}
+	return &CounterVec{f: f}, nil
+}
+
+//TimerVec declares a family of timers with the given labels,keeping at most maxChildren label values if maxChildren > 0.
+//Its children are built with the defaults set by SetDefaults for registry.KindTimer,declaring it again is like for CounterVec
+func (r *RegistryWrapper) TimerVec(name string, maxChildren int, labels ...string) (*TimerVec, error) {
+	f, err := r.declare(registry.KindTimer, name, maxChildren, labels)
+	if err != nil {
+		return nil, err
+	}
+	return &TimerVec{f: f}, nil
+}
+
+//HistogramVec declares a family of histograms with the given labels,keeping at most maxChildren label values if maxChildren > 0.
+//Its children are built with the defaults set by SetDefaults for registry.KindHistogram,declaring it again is like for CounterVec
+func (r *RegistryWrapper) HistogramVec(name string, maxChildren int, labels ...string) (*HistogramVec, error) {
+	f, err := r.declare(registry.KindHistogram, name, maxChildren, labels)
+	if err != nil {
+		return nil, err
+	}
+	return &HistogramVec{f: f}, nil
+}
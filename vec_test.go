@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/carbin-gun/awesome-metrics/mechanism"
+	"github.com/carbin-gun/awesome-metrics/output"
+	"github.com/carbin-gun/awesome-metrics/registry"
+)
+
+func TestVecFreesUnregisteredChildren(t *testing.T) {
+	tests := []struct {
+		name       string
+		unregister func(r *RegistryWrapper)
+	}{
+		{"UnregisterAll", func(r *RegistryWrapper) { r.Registry.UnregisterAll() }},
+		{"UnregisterTagged", func(r *RegistryWrapper) { r.Registry.UnregisterTagged(registry.NewMetricName("req", "cust", "a")) }},
+		{"UnregisterScope", func(r *RegistryWrapper) { r.Registry.UnregisterScope("api") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := NewRegistry()
+			r := root
+			if tt.name == "UnregisterScope" {
+				r = root.Scope("api")
+			}
+			vec, err := r.CounterVec("req", 1, "cust")
+			if err != nil {
+				t.Fatal(err)
+			}
+			vec.WithLabelValues("a").Inc(1)
+			tt.unregister(root)
+			vec.WithLabelValues("b").Inc(1)
+			if r.Registry.GetTagged(registry.NewMetricName("req", "cust", OverflowLabelValue)) != nil {
+				t.Fatal("the label value went to the overflow child")
+			}
+			if r.Registry.GetTagged(registry.NewMetricName("req", "cust", "b")) == nil {
+				t.Fatal("the child of the new label value isn't registered")
+			}
+		})
+	}
+}
+
+func TestVecRemoveFreesChild(t *testing.T) {
+	r := NewRegistry()
+	vec, err := r.TimerVec("latency", 1, "route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec.WithLabelValues("/a")
+	if !vec.Remove("/a") {
+		t.Fatal("Remove returned false for an existing child")
+	}
+	vec.WithLabelValues("/b")
+	if r.Registry.GetTagged(registry.NewMetricName("latency", "route", "/b")) == nil {
+		t.Fatal("the child of the new label value isn't registered")
+	}
+}
+
+func TestVecChildrenUseDefaults(t *testing.T) {
+	r := NewRegistry()
+	r.SetDefaults(registry.KindTimer, WithBuckets(1e6, 1e7))
+	r.SetDefaults(registry.KindHistogram, WithBuckets(10, 100), WithDescription("sizes"))
+	scope := r.Scope("api")
+	timers, err := scope.TimerVec("latency", 0, "route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	histograms, err := scope.HistogramVec("size", 0, "route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	timer := timers.WithLabelValues("/")
+	histogram := histograms.WithLabelValues("/")
+	if _, ok := timer.Snapshot().(output.Bucketed); !ok {
+		t.Error("the timer child doesn't use the buckets of the timer defaults")
+	}
+	if _, ok := histogram.Snapshot().(output.Bucketed); !ok {
+		t.Error("the histogram child doesn't use the buckets of the histogram defaults")
+	}
+	name := registry.NewMetricName("size", "route", "/")
+	if d, _ := scope.Registry.Describe(name.String()); d.Description != "sizes" {
+		t.Errorf("Describe() = %+v, want the description of the histogram defaults", d)
+	}
+}
+
+func TestVecOverflowsBeyondCap(t *testing.T) {
+	r := NewRegistry()
+	vec, err := r.CounterVec("req", 2, "cust")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cust := range []string{"a", "b", "c", "d", "a"} {
+		vec.WithLabelValues(cust).Inc(1)
+	}
+	tests := []struct {
+		cust string
+		want int64 //-1 if the child must not be registered
+	}{
+		{"a", 2},
+		{"b", 1},
+		{"c", -1},
+		{"d", -1},
+		{OverflowLabelValue, 2},
+	}
+	for _, tt := range tests {
+		m := r.Registry.GetTagged(registry.NewMetricName("req", "cust", tt.cust))
+		if tt.want < 0 {
+			if m != nil {
+				t.Errorf("the child of %s is registered beyond the cap", tt.cust)
+			}
+			continue
+		}
+		if c, ok := m.(mechanism.Counter); !ok || c.Count() != tt.want {
+			t.Errorf("the child of %s = %v,want a count of %d", tt.cust, m, tt.want)
+		}
+	}
+}
+
+func TestVecDeclaredOnce(t *testing.T) {
+	r := NewRegistry()
+	first, err := r.Scope("api").CounterVec("req", 1, "cust")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := r.Scope("api").CounterVec("req", 1, "cust")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.f != second.f {
+		t.Fatal("declaring the family twice created another family")
+	}
+	first.WithLabelValues("a")
+	second.WithLabelValues("b")
+	if r.Registry.GetTagged(registry.NewMetricName("api.req", "cust", OverflowLabelValue)) == nil {
+		t.Error("the declarations don't share the cap")
+	}
+	if other, err := r.CounterVec("req", 1, "cust"); err != nil || other.f == first.f {
+		t.Errorf("the family of another scope = %v,%v,want a new family", other, err)
+	}
+	tests := []struct {
+		name    string
+		declare func() error
+	}{
+		{"other labels", func() error {
+			_, err := r.Scope("api").CounterVec("req", 1, "cust", "route")
+			return err
+		}},
+		{"other kind", func() error {
+			_, err := r.Scope("api").TimerVec("req", 1, "cust")
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := tt.declare().(*VecMismatchError); !ok {
+				t.Error("the mismatching declaration didn't return a *VecMismatchError")
+			}
+		})
+	}
+}
+
+func TestVecFreesRejectedChildren(t *testing.T) {
+	r := NewRegistry()
+	r.Registry.RegisterTagged(registry.NewMetricName("req", "cust", "a"), NewGauge())
+	vec, err := r.CounterVec("req", 1, "cust")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := vec.WithLabelValues("a"); c == nil {
+		t.Fatal("no counter returned for a name held by a gauge")
+	}
+	vec.WithLabelValues("b")
+	if r.Registry.GetTagged(registry.NewMetricName("req", "cust", "b")) == nil {
+		t.Error("the rejected child still counts against the cap")
+	}
+}
+
+func TestVecPanicsOnWrongLabelCount(t *testing.T) {
+	r := NewRegistry()
+	vec, err := r.CounterVec("req", 0, "cust", "route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a missing label value")
+		}
+	}()
+	vec.WithLabelValues("a")
+}